@@ -1,26 +1,56 @@
 package cmd
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"os"
-	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"text/tabwriter"
 
 	"github.com/dirtydriver/projgen/filescheck"
+	"github.com/dirtydriver/projgen/hooks"
+	"github.com/dirtydriver/projgen/manifest"
+	"github.com/dirtydriver/projgen/merge"
 	"github.com/dirtydriver/projgen/project"
+	"github.com/dirtydriver/projgen/prompt"
+	"github.com/dirtydriver/projgen/schema"
+	"github.com/dirtydriver/projgen/source"
 	"github.com/dirtydriver/projgen/templater"
 	"github.com/dirtydriver/projgen/utils"
 	"github.com/dirtydriver/projgen/version"
 	"github.com/spf13/cobra"
+	"sigs.k8s.io/yaml"
 )
 
 var (
-	projectType    string
-	projectName    string
-	outputDir      string
-	parameters     []string
-	templateDir    string
-	parametersFile string
+	projectTypes    []string
+	projectName     string
+	outputDir       string
+	parameters      []string
+	templateDir     string
+	parametersFiles []string
+	noInput         bool
+	noHooks         bool
+	dryRun          bool
+	templateSHA256  string
+	offline         bool
+	outputFormat    string
+	inspectOutput   string
+	fromSource      string
+	onConflict      string
+
+	// stringParameters are the --set-string overrides, applied after --parameter.
+	stringParameters []string
+	// listMerge is how lists of later parameter files are merged, see utils.DeepMerge.
+	listMerge   string
+	printParams bool
+
+	// templateSource is the resolved --template-dir, see resolveSource.
+	templateSource *source.Source
 )
 
 func getRootCmd() *cobra.Command {
@@ -30,14 +60,20 @@ func getRootCmd() *cobra.Command {
 	}
 
 	// Add shared flags that apply to multiple commands
-	rootCmd.PersistentFlags().StringVar(&templateDir, "template-dir", "", "Path to the template directory")
-	rootCmd.PersistentFlags().StringVarP(&projectType, "type", "t", "", "Type of project (e.g. maven, gradle, angular)")
+	rootCmd.PersistentFlags().StringVar(&templateDir, "template-dir", "", "Path to the template directory, a git repository as git+<url>[@ref], or a .tar.gz/.zip archive path or URL")
+	rootCmd.PersistentFlags().StringVar(&templateSHA256, "template-sha256", "", "Expected SHA-256 checksum of a template archive")
+	rootCmd.PersistentFlags().BoolVar(&offline, "offline", false, "Only use templates already in the cache, never download")
+	rootCmd.PersistentFlags().StringArrayVarP(&projectTypes, "type", "t", []string{}, "Type of project (e.g. maven, gradle, angular); repeat to combine several types")
 
 	// Add all subcommands
 	rootCmd.AddCommand(
 		getVersionCmd(),
 		getGenerateCmd(),
 		getInspectCmd(),
+		getSchemaCmd(),
+		getValidateCmd(),
+		getListCmd(),
+		getUpdateCmd(),
 	)
 
 	return rootCmd
@@ -51,44 +87,137 @@ func getGenerateCmd() *cobra.Command {
 			if templateDir == "" {
 				return fmt.Errorf("required flag \"template-dir\" not set")
 			}
-			if projectType == "" {
+			if len(projectTypes) == 0 {
 				return fmt.Errorf("required flag \"type\" not set")
 			}
+			if !slices.Contains(project.OnConflictPolicies, onConflict) {
+				return fmt.Errorf("unsupported conflict policy %q, expected one of %s", onConflict, strings.Join(project.OnConflictPolicies, ", "))
+			}
+			if onConflict == project.OnConflictPrompt && !prompt.IsTerminal(os.Stdin) {
+				return fmt.Errorf("--on-conflict=prompt requires an interactive terminal")
+			}
 			return nil
 		},
 		Run: func(cmd *cobra.Command, args []string) {
 			// Collect additional parameters passed via --parameter flags
 			paramsMap := make(map[string]interface{})
+			sources := make(utils.Sources)
+
+			if err := readParamsFiles(paramsMap, sources); err != nil {
+				log.Fatal(err.Error())
+			}
+
+			if err := applyOverrides(paramsMap, sources); err != nil {
+				log.Fatalf("Invalid parameters:\n%v", err)
+			}
 
-			if parametersFile != "" {
-				if err := filescheck.ReadParamsFromYaml(parametersFile, &paramsMap); err != nil {
-					log.Fatal(err.Error())
+			templates, err := loadTemplates()
+			if err != nil {
+				log.Fatalf("Error loading template: %v", err)
+			}
+			m := mergedManifest(templates)
+			applyDefaults(m, paramsMap, sources)
+			if err := m.ValidateParams(paramsMap); err != nil {
+				log.Fatalf("Invalid parameters:\n%v", err)
+			}
+			if printParams {
+				if err := printEffectiveParams(paramsMap, sources, m); err != nil {
+					log.Fatalf("Error writing parameters: %v", err)
 				}
+				return
 			}
 
-			utils.ApplyOverrides(paramsMap, parameters)
-			templatePath := path.Join(templateDir, projectType)
+			params, err := collectParameters(templates)
+			if err != nil {
+				log.Fatalf("Invalid templates:\n%v", err)
+			}
 
-			files, err := filescheck.FindTemplateFiles(templatePath, "tmpl")
+			// A dry run neither runs hooks nor touches the output directory.
+			var snapshot *project.Snapshot
+			if !dryRun {
+				snapshot, err = project.TakeSnapshot(outputDir)
+				if err != nil {
+					log.Fatalf("Error inspecting output directory: %v", err)
+				}
+			}
 
+			if !noHooks && !dryRun {
+				if err := runHooks(hooks.Pre, templates, paramsMap); err != nil {
+					rollback(snapshot)
+					log.Fatalf("Error running hooks: %v", err)
+				}
+				// Pre hooks may change the parameters, check them again.
+				if err := m.ValidateParams(paramsMap); err != nil {
+					rollback(snapshot)
+					log.Fatalf("Invalid parameters after pre hooks:\n%v", err)
+				}
+			}
+
+			if missing := utils.MissingKeys(paramsMap, requiredNames(params, m, paramsMap)); len(missing) > 0 && !noInput && prompt.IsTerminal(os.Stdin) {
+				if err := promptMissing(paramsMap, missing, m); err != nil {
+					rollback(snapshot)
+					log.Fatalf("Error reading parameters: %v", err)
+				}
+			}
+
+			err = utils.CheckMissingKeys(paramsMap, requiredNames(params, m, paramsMap))
 			if err != nil {
-				log.Fatalf("Error collecting template files: %v", err)
+				rollback(snapshot)
+				log.Fatalf("Error checking missing keys: %v", err)
 			}
 
-			params, err := templater.CollectParameters(files)
+			// Plan all templates first so that conflicting output paths are reported before writing.
+			entries, err := project.Compose(templates, paramsMap)
 			if err != nil {
-				log.Fatalf("Error collecting parameters: %v", err)
+				rollback(snapshot)
+				log.Fatalf("Error planning project: %v", err)
 			}
 
-			err = utils.CheckMissingKeys(paramsMap, params)
+			// Record the answers so that the project can be audited and updated later. The answers
+			// file is planned like the other files, so the conflict policy and dry runs apply to it.
+			answers, err := project.NewAnswers(templateSource, templates, paramsMap)
+			var answersEntry project.Entry
+			if err == nil {
+				answersEntry, err = answers.Entry()
+			}
 			if err != nil {
-				log.Fatalf("Error checking missing keys: %v", err)
+				rollback(snapshot)
+				log.Fatalf("Error recording answers: %v", err)
+			}
+			entries = append(entries, answersEntry)
+
+			if dryRun {
+				plan := &project.PlanWriter{Dir: outputDir}
+				if err := project.Write(entries, plan, paramsMap); err != nil {
+					log.Fatalf("Error rendering project: %v", err)
+				}
+				if err := printPlan(plan); err != nil {
+					log.Fatalf("Error writing plan: %v", err)
+				}
+				return
+			}
+
+			// Report every file that would be overwritten before writing anything.
+			if onConflict == project.OnConflictFail {
+				if err := project.Preflight(entries, outputDir, paramsMap); err != nil {
+					rollback(snapshot)
+					log.Fatalf("Error generating project: %v\nUse --on-conflict=skip|overwrite|backup|prompt to write into the existing files.", err)
+				}
 			}
 
-			err = project.Generate(templatePath, outputDir, paramsMap)
+			writer := &project.DiskWriter{Dir: outputDir, OnConflict: onConflict, Confirm: confirmOverwrite(prompt.New(os.Stdin, os.Stdout)), Snapshot: snapshot}
+			err = project.Write(entries, writer, paramsMap)
 			if err != nil {
+				rollback(snapshot)
 				log.Fatalf("Error generating project: %v", err)
 			}
+
+			if !noHooks {
+				if err := runHooks(hooks.Post, templates, paramsMap); err != nil {
+					rollback(snapshot)
+					log.Fatalf("Error running hooks: %v", err)
+				}
+			}
 			fmt.Println("Project generated successfully!")
 		},
 	}
@@ -96,43 +225,736 @@ func getGenerateCmd() *cobra.Command {
 	// Add flags specific to generate command
 	cmd.Flags().StringVarP(&projectName, "name", "n", "", "Name of the project (can also be provided via --parameter name=value)")
 	cmd.Flags().StringVarP(&outputDir, "out", "o", ".", "Output directory")
-	cmd.Flags().StringVarP(&parametersFile, "file", "f", "", "Path to the parameters file")
-	cmd.Flags().StringArrayVarP(&parameters, "parameter", "p", []string{}, "Additional parameters in key=value format")
+	cmd.Flags().StringArrayVarP(&parametersFiles, "file", "f", []string{}, "Path to a parameters file; repeat to layer several files, later files win")
+	cmd.Flags().StringVar(&listMerge, "list-merge", utils.ListsReplace, "How lists of later parameter files are merged: replace or append")
+	cmd.Flags().BoolVar(&printParams, "print-params", false, "Print the effective parameters and where each value came from, then exit")
+	cmd.Flags().StringArrayVarP(&parameters, "parameter", "p", []string{}, "Additional parameters in key=value format; values are read as YAML, key+=value appends to a list, key- removes a key")
+	cmd.Flags().StringArrayVar(&stringParameters, "set-string", []string{}, "Like --parameter, but the value is always a string")
+	cmd.Flags().BoolVar(&noInput, "no-input", false, "Never prompt for missing parameters, fail instead")
+	cmd.Flags().BoolVar(&noHooks, "no-hooks", false, "Do not run the template's hooks (recommended for untrusted templates)")
+	cmd.Flags().StringVar(&onConflict, "on-conflict", project.OnConflictFail, "What to do with existing files that would change: fail, skip, overwrite, backup (keep a .orig copy) or prompt")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Render the project and print what would be written, without writing anything or running hooks")
 
 	return cmd
 }
 
 func getInspectCmd() *cobra.Command {
-	return &cobra.Command{
+	cmd := &cobra.Command{
 		Use:   "inspect",
 		Short: "Inspect template parameters and requirements",
 		PreRunE: func(cmd *cobra.Command, args []string) error {
 			if templateDir == "" {
 				return fmt.Errorf("required flag \"template-dir\" not set")
 			}
-			if projectType == "" {
+			if len(projectTypes) == 0 {
 				return fmt.Errorf("required flag \"type\" not set")
 			}
+			if !slices.Contains([]string{"table", "json", "yaml"}, inspectOutput) {
+				return fmt.Errorf("unsupported output format %q, expected table, json or yaml", inspectOutput)
+			}
 			return nil
 		},
 		Run: func(cmd *cobra.Command, args []string) {
-			templatePath := path.Join(templateDir, projectType)
-			files, err := filescheck.FindTemplateFiles(templatePath, "tmpl")
+			templates, err := loadTemplates()
+			if err != nil {
+				log.Fatalf("Error loading template: %v", err)
+			}
+
+			params, err := collectParameters(templates)
+			if err != nil {
+				log.Fatalf("Invalid templates:\n%v", err)
+			}
+			infos := project.DescribeParameters(params, mergedManifest(templates), templateSource.Dir)
+
+			switch inspectOutput {
+			case "json":
+				encoder := json.NewEncoder(os.Stdout)
+				encoder.SetIndent("", "  ")
+				if err := encoder.Encode(infos); err != nil {
+					log.Fatalf("Error encoding parameters: %v", err)
+				}
+			case "yaml":
+				data, err := yaml.Marshal(infos)
+				if err != nil {
+					log.Fatalf("Error encoding parameters: %v", err)
+				}
+				os.Stdout.Write(data)
+			default:
+				if err := printParameters(infos); err != nil {
+					log.Fatalf("Error writing parameters: %v", err)
+				}
+			}
+		},
+	}
+
+	cmd.Flags().StringVar(&inspectOutput, "output", "table", "Output format: table, json or yaml")
+
+	return cmd
+}
+
+func getSchemaCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "schema",
+		Short: "Print a JSON Schema of the parameters a template accepts",
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if templateDir == "" {
+				return fmt.Errorf("required flag \"template-dir\" not set")
+			}
+			if len(projectTypes) == 0 {
+				return fmt.Errorf("required flag \"type\" not set")
+			}
+			return nil
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			templates, err := loadTemplates()
+			if err != nil {
+				log.Fatalf("Error loading template: %v", err)
+			}
+			params, err := collectParameters(templates)
+			if err != nil {
+				log.Fatalf("Invalid templates:\n%v", err)
+			}
+			s := parametersSchema(templates, params)
+
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+			if err := encoder.Encode(s); err != nil {
+				log.Fatalf("Error encoding schema: %v", err)
+			}
+		},
+	}
+}
+
+// parametersSchema builds the JSON Schema of the parameters accepted by the templates.
+func parametersSchema(templates []*project.Template, params []templater.Parameter) *schema.Schema {
+	m := mergedManifest(templates)
+	title := m.Name
+	if title == "" {
+		title = strings.Join(projectTypes, "+")
+	}
+	return schema.FromParameters(title, m.Description, project.DescribeParameters(params, m, templateSource.Dir))
+}
+
+func getValidateCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "validate",
+		Short: "Check parameters against a template without generating anything",
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if templateDir == "" {
+				return fmt.Errorf("required flag \"template-dir\" not set")
+			}
+			if len(projectTypes) == 0 {
+				return fmt.Errorf("required flag \"type\" not set")
+			}
+			return nil
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			paramsMap := make(map[string]interface{})
+			sources := make(utils.Sources)
+			problems := flattenErrors(readParamsFiles(paramsMap, sources))
+			problems = append(problems, flattenErrors(applyOverrides(paramsMap, sources))...)
+
+			templates, err := loadTemplates()
+			if err != nil {
+				reportProblems(append(problems, fmt.Errorf("loading template: %w", err)))
+			}
+			params, err := collectParameters(templates)
+			if err != nil {
+				reportProblems(append(problems, flattenErrors(err)...))
+			}
+
+			m := mergedManifest(templates)
+			applyDefaults(m, paramsMap, sources)
+			problems = append(problems, flattenErrors(m.ValidateParams(paramsMap))...)
+			if printParams {
+				if err := printEffectiveParams(paramsMap, sources, m); err != nil {
+					log.Fatalf("Error writing parameters: %v", err)
+				}
+			}
+			for _, key := range utils.MissingKeys(paramsMap, requiredNames(params, m, paramsMap)) {
+				// The schema reports the elements of lists one by one, e.g. items[1].id.
+				if strings.Contains(key, "[]") {
+					continue
+				}
+				problems = append(problems, &manifest.FieldError{Field: key, Err: errors.New("is required")})
+			}
+			// The schema repeats the manifest checks; only report values that were not reported yet.
+			for _, err := range flattenErrors(parametersSchema(templates, params).Validate(paramsMap)) {
+				var fieldErr *manifest.FieldError
+				if errors.As(err, &fieldErr) && slices.ContainsFunc(problems, func(p error) bool {
+					var reported *manifest.FieldError
+					return errors.As(p, &reported) && reported.Field == fieldErr.Field
+				}) {
+					continue
+				}
+				problems = append(problems, err)
+			}
+
+			entries, err := project.Compose(templates, paramsMap)
+			if err == nil {
+				err = project.TrialRender(entries, paramsMap)
+			}
+			problems = append(problems, flattenErrors(err)...)
+
+			if len(problems) > 0 {
+				reportProblems(problems)
+			}
+			fmt.Println("Parameters are valid.")
+		},
+	}
+
+	cmd.Flags().StringArrayVarP(&parametersFiles, "file", "f", []string{}, "Path to a parameters file; repeat to layer several files, later files win")
+	cmd.Flags().StringVar(&listMerge, "list-merge", utils.ListsReplace, "How lists of later parameter files are merged: replace or append")
+	cmd.Flags().BoolVar(&printParams, "print-params", false, "Print the effective parameters and where each value came from before checking them")
+	cmd.Flags().StringArrayVarP(&parameters, "parameter", "p", []string{}, "Additional parameters in key=value format; values are read as YAML, key+=value appends to a list, key- removes a key")
+	cmd.Flags().StringArrayVar(&stringParameters, "set-string", []string{}, "Like --parameter, but the value is always a string")
+
+	return cmd
+}
+
+// reportProblems prints the problems found by validate and exits with status 1.
+func reportProblems(problems []error) {
+	fmt.Fprintf(os.Stderr, "Found %d problem(s):\n", len(problems))
+	for _, p := range problems {
+		fmt.Fprintf(os.Stderr, "  - %v\n", p)
+	}
+	os.Exit(1)
+}
+
+// flattenErrors returns the errors combined by errors.Join, or err itself.
+func flattenErrors(err error) []error {
+	if err == nil {
+		return nil
+	}
+	joined, ok := err.(interface{ Unwrap() []error })
+	if !ok {
+		return []error{err}
+	}
+	var errs []error
+	for _, e := range joined.Unwrap() {
+		errs = append(errs, flattenErrors(e)...)
+	}
+	return errs
+}
+
+// resolveSource resolves --template-dir, fetching remote template sources into the cache.
+// The result is kept for the rest of the command.
+func resolveSource() (*source.Source, error) {
+	if templateSource != nil {
+		return templateSource, nil
+	}
+	src, err := source.Resolve(templateDir, source.Options{SHA256: templateSHA256, Offline: offline})
+	if err != nil {
+		return nil, err
+	}
+	if src.Kind != source.KindDir {
+		fmt.Fprintf(os.Stderr, "Using templates from %s\n", src)
+	}
+	templateSource = src
+	return src, nil
+}
+
+// loadTemplates loads the template of every selected project type.
+func loadTemplates() ([]*project.Template, error) {
+	src, err := resolveSource()
+	if err != nil {
+		return nil, err
+	}
+	return loadTypes(src.Dir, projectTypes)
+}
+
+// loadTypes loads the templates of the given types from a template root.
+func loadTypes(root string, types []string) ([]*project.Template, error) {
+	var templates []*project.Template
+	for _, projectType := range utils.RemoveDuplicates(types) {
+		tmpl, err := project.LoadType(root, projectType)
+		if err != nil {
+			return nil, err
+		}
+		templates = append(templates, tmpl)
+	}
+	return templates, nil
+}
+
+// mergedManifest combines the manifests of all selected templates into one parameter set,
+// later types overriding declarations of earlier ones.
+func mergedManifest(templates []*project.Template) *manifest.Manifest {
+	m := &manifest.Manifest{}
+	for _, tmpl := range templates {
+		m = manifest.Merge(m, tmpl.Manifest)
+	}
+	return m
+}
+
+// collectParameters describes the parameters used by the template files and the file names of all templates.
+func collectParameters(templates []*project.Template) ([]templater.Parameter, error) {
+	var files [][]string
+	for _, tmpl := range templates {
+		analysisFiles, err := tmpl.AnalysisFiles()
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, analysisFiles...)
+	}
+	return templater.AnalyzeLayered(files)
+}
+
+// readParamsFiles deep merges the --file parameter files into paramsMap in order and records the
+// file each value came from in sources. Files that cannot be read or merged are skipped and their
+// errors returned together.
+func readParamsFiles(paramsMap map[string]interface{}, sources utils.Sources) error {
+	if !slices.Contains(utils.ListMergeModes, listMerge) {
+		return fmt.Errorf("unsupported list merge mode %q, expected one of %s", listMerge, strings.Join(utils.ListMergeModes, ", "))
+	}
+	var errs []error
+	for _, file := range parametersFiles {
+		layer := make(map[string]interface{})
+		if err := filescheck.ReadParamsFromYaml(file, &layer); err != nil {
+			errs = append(errs, fmt.Errorf("reading %s: %w", file, err))
+			continue
+		}
+		if err := utils.DeepMerge(paramsMap, layer, listMerge, func(path string) { sources.Set(path, file) }); err != nil {
+			errs = append(errs, fmt.Errorf("merging %s: %w", file, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// applyOverrides applies the --parameter and then the --set-string overrides to paramsMap and
+// records them in sources.
+func applyOverrides(paramsMap map[string]interface{}, sources utils.Sources) error {
+	return errors.Join(
+		utils.ApplyOverrides(paramsMap, parameters, func(path string) { sources.Set(path, "--parameter") }),
+		utils.ApplyStringOverrides(paramsMap, stringParameters, func(path string) { sources.Set(path, "--set-string") }),
+	)
+}
+
+// applyDefaults applies the declared defaults of the manifest to paramsMap and records them in sources.
+func applyDefaults(m *manifest.Manifest, paramsMap map[string]interface{}, sources utils.Sources) {
+	for _, p := range m.Parameters {
+		if _, ok := utils.LookupKey(paramsMap, p.Name); !ok && p.Default != nil {
+			sources.Set(p.Name, "default")
+		}
+	}
+	m.ApplyDefaults(paramsMap)
+}
+
+// printEffectiveParams prints every value of paramsMap with its source. Secret values are masked.
+func printEffectiveParams(paramsMap map[string]interface{}, sources utils.Sources, m *manifest.Manifest) error {
+	secrets := m.SecretNames()
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "PARAMETER\tVALUE\tSOURCE")
+	utils.WalkLeaves(paramsMap, func(path string, value interface{}) {
+		shown := "null"
+		if value != nil {
+			shown = fmt.Sprint(value)
+		}
+		if slices.ContainsFunc(secrets, func(name string) bool {
+			return path == name || strings.HasPrefix(path, name+".") || strings.HasPrefix(path, name+"[")
+		}) {
+			shown = "********"
+		}
+		source := sources.Lookup(path)
+		if source == "" {
+			source = "-"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", path, shown, source)
+	})
+	return w.Flush()
+}
+
+// requiredNames returns the parameters that must be set for the given values: those the templates
+// need and those the manifest declares as required.
+func requiredNames(params []templater.Parameter, m *manifest.Manifest, paramsMap map[string]interface{}) []string {
+	return utils.RemoveDuplicates(append(templater.RequiredNames(params, paramsMap), m.RequiredNames()...))
+}
+
+// runHooks runs the hooks of a stage of every template in the output directory, creating it if needed.
+func runHooks(stage hooks.Stage, templates []*project.Template, paramsMap map[string]interface{}) error {
+	// A parent template extended by several of the templates runs its hooks once.
+	ran := make(map[string]bool)
+	for _, tmpl := range templates {
+		var layers []string
+		m := &manifest.Manifest{}
+		for _, layer := range tmpl.Layers {
+			if ran[layer] {
+				continue
+			}
+			ran[layer] = true
+			layerManifest, err := manifest.Load(layer)
 			if err != nil {
-				log.Fatalf("Error collecting template files: %v", err)
+				return err
 			}
+			layers = append(layers, layer)
+			m = manifest.Merge(m, layerManifest)
+		}
+		list, err := hooks.Collect(layers, m, stage)
+		if err != nil {
+			return err
+		}
+		if len(list) == 0 {
+			continue
+		}
+		if err := os.MkdirAll(outputDir, 0755); err != nil {
+			return err
+		}
+		if err := hooks.Run(list, stage, tmpl.Dir, outputDir, paramsMap); err != nil {
+			return err
+		}
+	}
+	return nil
+}
 
-			params, err := templater.CollectParameters(files)
+// rollback removes what a failed generation created in the output directory and restores the files it replaced.
+func rollback(snapshot *project.Snapshot) {
+	if snapshot == nil {
+		return
+	}
+	if err := snapshot.Rollback(); err != nil {
+		log.Printf("Error rolling back generated files: %v", err)
+	}
+}
+
+// printPlan prints the files a dry run would write, followed by a summary.
+func printPlan(plan *project.PlanWriter) error {
+	fmt.Printf("Dry run, nothing is written to %s\n\n", plan.Dir)
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "STATUS\tSOURCE\tMODE\tSIZE\tPATH")
+	counts := make(map[string]int)
+	for _, a := range plan.Actions {
+		counts[a.Status]++
+		kind, mode, size, path := "copied", fmt.Sprintf("%04o", a.Mode), fmt.Sprintf("%d B", a.Size), filepath.ToSlash(a.Target)
+		switch {
+		case a.Status == project.StatusSkipped:
+			mode, size = "-", "-"
+			path += " (" + a.Reason + ")"
+		case a.Dir:
+			kind, size = "directory", "-"
+			path += "/"
+		case a.Link != "":
+			kind, mode, size = "symlink", "-", "-"
+			path += " -> " + a.Link
+		}
+		if a.Rendered {
+			kind = "rendered"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", a.Status, kind, mode, size, path)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	fmt.Printf("\n%d to create, %d to overwrite, %d unchanged, %d skipped\n",
+		counts[project.StatusCreate], counts[project.StatusOverwrite], counts[project.StatusUnchanged], counts[project.StatusSkipped])
+	return nil
+}
+
+// confirmOverwrite returns a DiskWriter.Confirm function showing the diff of an existing file and
+// asking whether to replace it.
+func confirmOverwrite(p *prompt.Prompter) func(target string, existing, content []byte) (bool, error) {
+	return func(target string, existing, content []byte) (bool, error) {
+		target = filepath.ToSlash(target)
+		fmt.Print(merge.Unified(existing, content, "a/"+target, "b/"+target))
+		return p.Confirm(fmt.Sprintf("Overwrite %s?", target))
+	}
+}
+
+// promptMissing interactively asks for every missing parameter and stores the answers in paramsMap.
+func promptMissing(paramsMap map[string]interface{}, missing []string, m *manifest.Manifest) error {
+	fmt.Println("Please provide values for the missing parameters:")
+	p := prompt.New(os.Stdin, os.Stdout)
+	for _, key := range missing {
+		// The elements of a list, such as items[].name, cannot be asked for one by one.
+		if strings.Contains(key, "[]") {
+			continue
+		}
+		value, err := p.Ask(key, m.Parameter(key))
+		if err != nil {
+			return err
+		}
+		utils.SetKey(paramsMap, key, value)
+	}
+	return nil
+}
+
+// printParameters prints a table of the template parameters.
+func printParameters(infos []project.ParameterInfo) error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "PARAMETER\tTYPE\tREQUIRED\tDEFAULT\tUSED IN\tDESCRIPTION")
+	for _, info := range infos {
+		required, def := "no", "-"
+		switch {
+		case info.Required:
+			required = "yes"
+		case info.RequiredWhen != "":
+			required = "if " + info.RequiredWhen
+		case info.RequiredUnless != "":
+			required = "unless " + info.RequiredUnless
+		}
+		if info.Default != nil {
+			def = fmt.Sprint(info.Default)
+		}
+		typ, locations := info.Type, strings.Join(info.Locations, ", ")
+		if typ == "" {
+			typ = "-"
+		}
+		if locations == "" {
+			locations = "-"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", info.Path, typ, required, def, locations, info.Description)
+	}
+	return w.Flush()
+}
+
+func getListCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List the template types available in the template directory",
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if templateDir == "" {
+				return fmt.Errorf("required flag \"template-dir\" not set")
+			}
+			if outputFormat != "text" && outputFormat != "json" {
+				return fmt.Errorf("unsupported output format %q, expected text or json", outputFormat)
+			}
+			return nil
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			src, err := resolveSource()
+			if err != nil {
+				log.Fatalf("Error resolving template directory: %v", err)
+			}
+			infos, err := project.ListTemplates(src.Dir)
 			if err != nil {
-				log.Fatalf("Error collecting parameters: %v", err)
+				log.Fatalf("Error listing templates: %v", err)
+			}
+
+			if outputFormat == "json" {
+				encoder := json.NewEncoder(os.Stdout)
+				encoder.SetIndent("", "  ")
+				if err := encoder.Encode(infos); err != nil {
+					log.Fatalf("Error encoding templates: %v", err)
+				}
+				return
 			}
 
-			fmt.Println("Template requires the following parameters:")
-			for _, p := range params {
-				fmt.Println(" -", p)
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "TYPE\tNAME\tVERSION\tTAGS\tDESCRIPTION")
+			for _, info := range infos {
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", info.Type, info.Name, info.Version, strings.Join(info.Tags, ","), info.Description)
+			}
+			if err := w.Flush(); err != nil {
+				log.Fatalf("Error writing templates: %v", err)
+			}
+		},
+	}
+
+	cmd.Flags().StringVar(&outputFormat, "output", "text", "Output format: text or json")
+
+	return cmd
+}
+
+func getUpdateCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "update",
+		Short: "Update a generated project to a newer template version",
+		Long: `Update re-applies the template to a project generated by projgen.
+It reads the answers recorded in .projgen.yaml, renders the template version the project was
+generated from and the new version, and merges the difference into the project. Lines changed
+both by the template and in the project are marked with conflict markers.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			conflicts, err := runUpdate()
+			if err != nil {
+				log.Fatalf("Error updating project: %v", err)
+			}
+			if conflicts > 0 {
+				os.Exit(1)
 			}
 		},
 	}
+
+	cmd.Flags().StringVarP(&outputDir, "out", "o", ".", "Directory of the generated project")
+	cmd.Flags().StringVar(&fromSource, "from", "", "Template source of the version the project was generated from (required for local template directories)")
+	cmd.Flags().StringArrayVarP(&parametersFiles, "file", "f", []string{}, "Path to a parameters file overriding recorded answers; repeat to layer several files, later files win")
+	cmd.Flags().StringVar(&listMerge, "list-merge", utils.ListsReplace, "How lists of later parameter files are merged: replace or append")
+	cmd.Flags().BoolVar(&printParams, "print-params", false, "Print the effective parameters and where each value came from, then exit")
+	cmd.Flags().StringArrayVarP(&parameters, "parameter", "p", []string{}, "Parameters overriding recorded answers in key=value format; values are read as YAML, key+=value appends to a list, key- removes a key")
+	cmd.Flags().StringArrayVar(&stringParameters, "set-string", []string{}, "Like --parameter, but the value is always a string")
+	cmd.Flags().BoolVar(&noInput, "no-input", false, "Never prompt for missing parameters, fail instead")
+
+	return cmd
+}
+
+// runUpdate merges the changes between the template version a project was generated from and the
+// new version into the project and returns the number of files left with conflicts. The rendered
+// versions live in temporary directories that are removed before it returns.
+func runUpdate() (int, error) {
+	answers, err := project.ReadAnswers(outputDir)
+	if err != nil {
+		return 0, fmt.Errorf("reading recorded answers: %w", err)
+	}
+	if answers.Source == nil {
+		return 0, fmt.Errorf("%s does not record the template source", project.AnswersFile)
+	}
+
+	oldSource, err := previousSource(answers.Source)
+	if err != nil {
+		return 0, fmt.Errorf("resolving previous template version: %w", err)
+	}
+	// Without --template-dir the recorded source is fetched again, e.g. the latest commit of its branch.
+	if templateDir == "" {
+		templateDir = answers.Source.Spec
+	}
+	newSource, err := resolveSource()
+	if err != nil {
+		return 0, fmt.Errorf("resolving template: %w", err)
+	}
+
+	types := projectTypes
+	if len(types) == 0 {
+		types = answers.Types()
+	}
+	oldTemplates, err := loadTypes(oldSource.Dir, types)
+	if err != nil {
+		return 0, fmt.Errorf("loading previous template version: %w", err)
+	}
+	newTemplates, err := loadTypes(newSource.Dir, types)
+	if err != nil {
+		return 0, fmt.Errorf("loading template: %w", err)
+	}
+
+	paramsMap := answers.Parameters
+	if paramsMap == nil {
+		paramsMap = make(map[string]interface{})
+	}
+	sources := make(utils.Sources)
+	for key := range paramsMap {
+		sources.Set(utils.FormatKey(key), project.AnswersFile)
+	}
+	if err := readParamsFiles(paramsMap, sources); err != nil {
+		return 0, err
+	}
+	if err := applyOverrides(paramsMap, sources); err != nil {
+		return 0, fmt.Errorf("invalid parameters:\n%w", err)
+	}
+
+	// Parameters added by the new version get their defaults; secrets that were not recorded are asked for again.
+	m := mergedManifest(newTemplates)
+	applyDefaults(m, paramsMap, sources)
+	if err := m.ValidateParams(paramsMap); err != nil {
+		return 0, fmt.Errorf("invalid parameters:\n%w", err)
+	}
+	if printParams {
+		return 0, printEffectiveParams(paramsMap, sources, m)
+	}
+	oldParams, err := collectParameters(oldTemplates)
+	if err != nil {
+		return 0, fmt.Errorf("invalid templates:\n%w", err)
+	}
+	newParams, err := collectParameters(newTemplates)
+	if err != nil {
+		return 0, fmt.Errorf("invalid templates:\n%w", err)
+	}
+	params := append(oldParams, newParams...)
+	if missing := utils.MissingKeys(paramsMap, requiredNames(params, m, paramsMap)); len(missing) > 0 && !noInput && prompt.IsTerminal(os.Stdin) {
+		if err := promptMissing(paramsMap, missing, m); err != nil {
+			return 0, fmt.Errorf("reading parameters: %w", err)
+		}
+	}
+	if err := utils.CheckMissingKeys(paramsMap, requiredNames(params, m, paramsMap)); err != nil {
+		return 0, err
+	}
+
+	oldDir, err := renderTemporary(oldTemplates, paramsMap)
+	if err != nil {
+		return 0, fmt.Errorf("rendering previous template version: %w", err)
+	}
+	defer os.RemoveAll(oldDir)
+	newDir, err := renderTemporary(newTemplates, paramsMap)
+	if err != nil {
+		return 0, fmt.Errorf("rendering template: %w", err)
+	}
+	defer os.RemoveAll(newDir)
+
+	updates, err := project.Update(outputDir, oldDir, newDir)
+	if err != nil {
+		return 0, err
+	}
+	answers, err = project.NewAnswers(newSource, newTemplates, paramsMap)
+	if err == nil {
+		err = project.WriteAnswers(outputDir, answers)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("recording answers: %w", err)
+	}
+
+	conflicts := printUpdates(updates)
+	if conflicts > 0 {
+		fmt.Printf("%d file(s) have conflicts, resolve them before committing the update.\n", conflicts)
+	} else {
+		fmt.Println("Project updated successfully!")
+	}
+	return conflicts, nil
+}
+
+// previousSource resolves the exact template version a project was generated from:
+// the recorded commit of a git source or the recorded checksum of an archive.
+// Local directories have no history, so --from has to name the previous version.
+func previousSource(recorded *source.Source) (*source.Source, error) {
+	opts := source.Options{Offline: offline}
+	spec := fromSource
+	if spec == "" {
+		switch recorded.Kind {
+		case source.KindGit:
+			spec = "git+" + recorded.URL + "@" + recorded.Commit
+		case source.KindArchive:
+			spec, opts.SHA256 = recorded.Spec, recorded.SHA256
+		default:
+			return nil, fmt.Errorf("the template directory %s has no version history, pass the previous version with --from", recorded.Spec)
+		}
+	}
+	return source.Resolve(spec, opts)
+}
+
+// renderTemporary renders the templates into a new temporary directory, without running hooks.
+func renderTemporary(templates []*project.Template, paramsMap map[string]interface{}) (string, error) {
+	entries, err := project.Compose(templates, paramsMap)
+	if err != nil {
+		return "", err
+	}
+	dir, err := os.MkdirTemp("", "projgen-update-")
+	if err != nil {
+		return "", err
+	}
+	if err := project.Write(entries, &project.DiskWriter{Dir: dir}, paramsMap); err != nil {
+		os.RemoveAll(dir)
+		return "", err
+	}
+	return dir, nil
+}
+
+// printUpdates prints the files changed by an update and returns the number of conflicting files.
+func printUpdates(updates []project.FileUpdate) int {
+	if len(updates) == 0 {
+		fmt.Println("The project is up to date with the template.")
+		return 0
+	}
+	conflicts := 0
+	for _, u := range updates {
+		line := fmt.Sprintf("  %-9s %s", u.Status, filepath.ToSlash(u.Path))
+		switch {
+		case u.Rejected != "":
+			line += fmt.Sprintf(" (new version saved as %s)", filepath.ToSlash(u.Rejected))
+		case u.Conflicts > 0:
+			line += fmt.Sprintf(" (%d conflict(s))", u.Conflicts)
+		case u.Status == project.UpdateKept:
+			line += " (changed locally, template changes not applied)"
+		}
+		if u.Status == project.UpdateConflict {
+			conflicts++
+		}
+		fmt.Println(line)
+	}
+	return conflicts
 }
 
 func getVersionCmd() *cobra.Command {
@@ -2,6 +2,7 @@ package filescheck
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
 	"io/fs"
 	"os"
@@ -85,12 +86,10 @@ func ReadParamsFromFile(paramFilePath string, paramsMap *map[string]interface{})
 }
 
 // FilesInDirectories returns a list of all files (non-directories) in the specified directory and its subdirectories.
+// Paths ignored by the directory's .projgenignore file or the default ignore patterns are left out.
 func FilesInDirectories(dir string) ([]string, error) {
 	var files []string
-	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
-		}
+	err := walk(dir, func(path string, info os.FileInfo) error {
 		if !info.IsDir() {
 			files = append(files, path)
 		}
@@ -101,28 +100,55 @@ func FilesInDirectories(dir string) ([]string, error) {
 
 }
 
+// EmptyDirectories returns all directories below dir, not including dir itself, that have no entries.
+// Ignored directories are left out, as for FilesInDirectories.
+func EmptyDirectories(dir string) ([]string, error) {
+	var dirs []string
+	err := walk(dir, func(path string, info os.FileInfo) error {
+		if !info.IsDir() || path == dir {
+			return nil
+		}
+		entries, err := os.ReadDir(path)
+		if err != nil {
+			return err
+		}
+		if len(entries) == 0 {
+			dirs = append(dirs, path)
+		}
+		return nil
+	})
+
+	return dirs, err
+}
+
 // CopyFile copies a file from the source path to the target directory.
 func CopyFile(file string, targetDir string) error {
+	return CopyFileTo(file, filepath.Join(targetDir, filepath.Base(file)))
+}
 
+// CopyFileTo copies a file from the source path to the given destination path,
+// keeping the permissions of the source file.
+func CopyFileTo(file string, destPath string) error {
+
+	info, err := os.Stat(file)
+	if err != nil {
+		return err
+	}
 	input, err := os.ReadFile(file)
 	if err != nil {
 		return fmt.Errorf("reading file %q: %w", file, err)
 	}
-	destPath := filepath.Join(targetDir, filepath.Base(file))
-	if err := os.WriteFile(destPath, input, 0644); err != nil {
+	if err := os.WriteFile(destPath, input, info.Mode().Perm()); err != nil {
 		return err
 	}
-	return nil
+	return os.Chmod(destPath, info.Mode().Perm())
 }
 
 // FindTemplateFiles searches for files containing the specified pattern in their name within the given path.
+// Paths ignored by the directory's .projgenignore file or the default ignore patterns are left out.
 func FindTemplateFiles(path string, pattern string) ([]string, error) {
 	var files []string
-	err := filepath.Walk(path, func(path string, info fs.FileInfo, err error) error {
-
-		if err != nil {
-			return err
-		}
+	err := walk(path, func(path string, info fs.FileInfo) error {
 
 		if !info.IsDir() && strings.Contains(info.Name(), pattern) {
 			files = append(files, path)
@@ -163,3 +189,58 @@ func IsDirectoryExists(path string) bool {
 	}
 	return info.IsDir()
 }
+
+// Within reports whether path is dir or lexically below it.
+func Within(dir, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// ResolvesWithin reports whether path is still inside dir once the symbolic links in both are resolved.
+// Neither has to exist yet.
+func ResolvesWithin(dir, path string) (bool, error) {
+	// Relative paths are made absolute without cleaning them, see RealPath.
+	if !filepath.IsAbs(dir) || !filepath.IsAbs(path) {
+		wd, err := os.Getwd()
+		if err != nil {
+			return false, err
+		}
+		if !filepath.IsAbs(dir) {
+			dir = wd + string(filepath.Separator) + dir
+		}
+		if !filepath.IsAbs(path) {
+			path = wd + string(filepath.Separator) + path
+		}
+	}
+	realDir, err := RealPath(dir)
+	if err != nil {
+		return false, err
+	}
+	real, err := RealPath(path)
+	if err != nil {
+		return false, err
+	}
+	return Within(realDir, real), nil
+}
+
+// RealPath resolves the symbolic links in path like filepath.EvalSymlinks, also for paths whose
+// last components do not exist yet.
+func RealPath(path string) (string, error) {
+	resolved, err := filepath.EvalSymlinks(path)
+	if err == nil {
+		return resolved, nil
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		return "", err
+	}
+	// The path is split without cleaning it, so that in "l/.." the parent of the link target is meant.
+	i := strings.LastIndex(path, string(filepath.Separator))
+	if i <= 0 {
+		return path, nil
+	}
+	resolvedParent, err := RealPath(path[:i])
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(resolvedParent, path[i+1:]), nil
+}
@@ -92,6 +92,52 @@ func TestCopyFiles(t *testing.T) {
 	}
 }
 
+// TestCopyFileToKeepsMode verifies that the permissions of the source file are kept.
+func TestCopyFileToKeepsMode(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "mvnw")
+	if err := os.WriteFile(src, []byte("#!/bin/sh\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(src, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	dest := filepath.Join(dir, "copy")
+	if err := CopyFileTo(src, dest); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	info, err := os.Stat(dest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0755 {
+		t.Fatalf("Expected mode 0755, got %v", info.Mode().Perm())
+	}
+}
+
+// TestEmptyDirectories verifies that only directories without entries are returned.
+func TestEmptyDirectories(t *testing.T) {
+	dir := t.TempDir()
+	for _, d := range []string{"src/main", "src/test/resources", "docs"} {
+		if err := os.MkdirAll(filepath.Join(dir, d), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(dir, "src", "main", "App.java"), []byte("class App {}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	dirs, err := EmptyDirectories(dir)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	expected := []string{filepath.Join(dir, "docs"), filepath.Join(dir, "src", "test", "resources")}
+	if len(dirs) != len(expected) || dirs[0] != expected[0] || dirs[1] != expected[1] {
+		t.Fatalf("Expected %v, got %v", expected, dirs)
+	}
+}
+
 // TestCreateDirectory tests that CreateDirectory creates a directory if it does not exist
 // and returns an error if the directory already exists.
 func TestCreateDirectory(t *testing.T) {
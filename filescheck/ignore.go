@@ -0,0 +1,148 @@
+package filescheck
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/dirtydriver/projgen/manifest"
+	"github.com/dirtydriver/projgen/utils"
+)
+
+// IgnoreFile is the file at the root of a template directory listing paths that are never emitted.
+const IgnoreFile = ".projgenignore"
+
+// DefaultIgnorePatterns apply to every template directory: version control data, the manifest
+// and the ignore file itself.
+var DefaultIgnorePatterns = []string{
+	".git/",
+	"/" + manifest.FileName,
+	"/" + IgnoreFile,
+}
+
+// hooksPattern ignores the hooks directory of templates using hooks.
+const hooksPattern = "/" + manifest.HooksDir + "/"
+
+// Ignore matches paths against gitignore style patterns.
+type Ignore struct {
+	patterns []ignorePattern
+}
+
+type ignorePattern struct {
+	// glob is matched with utils.MatchGlob; unanchored patterns are prefixed with "**/".
+	glob    string
+	negate  bool
+	dirOnly bool
+}
+
+// ParseIgnore parses lines in gitignore syntax: blank lines and lines starting with # are skipped,
+// a leading ! re-includes what an earlier pattern excluded, a trailing / only matches directories,
+// and a pattern containing a / other than a trailing one is relative to the template root,
+// while other patterns match at any depth. "**" matches any number of directories.
+func ParseIgnore(lines []string) *Ignore {
+	ig := &Ignore{}
+	for _, line := range lines {
+		if !strings.HasSuffix(line, `\ `) {
+			line = strings.TrimRight(line, " \t\r")
+		}
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		var p ignorePattern
+		if strings.HasPrefix(line, "!") {
+			p.negate = true
+			line = line[1:]
+		} else if strings.HasPrefix(line, `\#`) || strings.HasPrefix(line, `\!`) {
+			line = line[1:]
+		}
+		if strings.HasSuffix(line, "/") {
+			p.dirOnly = true
+			line = strings.TrimSuffix(line, "/")
+		}
+		if line == "" {
+			continue
+		}
+		if strings.Contains(line, "/") {
+			p.glob = strings.TrimPrefix(line, "/")
+		} else {
+			p.glob = "**/" + line
+		}
+		ig.patterns = append(ig.patterns, p)
+	}
+	return ig
+}
+
+// LoadIgnore returns the default patterns, the hooks directory if the template in dir uses hooks,
+// followed by the patterns of the .projgenignore file in dir, if there is one.
+func LoadIgnore(dir string) (*Ignore, error) {
+	lines := append([]string{}, DefaultIgnorePatterns...)
+	m, err := manifest.Load(dir)
+	if err != nil {
+		return nil, err
+	}
+	usesHooks, err := manifest.UsesHooksDir(dir, m)
+	if err != nil {
+		return nil, err
+	}
+	if usesHooks {
+		lines = append(lines, hooksPattern)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, IgnoreFile))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return ParseIgnore(lines), nil
+}
+
+// Match reports whether a slash separated path relative to the template root is ignored.
+// The last matching pattern decides. Paths inside ignored directories are not matched here;
+// callers walking a tree skip ignored directories altogether, as git does.
+func (ig *Ignore) Match(relPath string, isDir bool) bool {
+	ignored := false
+	for _, p := range ig.patterns {
+		if p.dirOnly && !isDir {
+			continue
+		}
+		if utils.MatchGlob(p.glob, relPath) {
+			ignored = !p.negate
+		}
+	}
+	return ignored
+}
+
+// walk calls fn for every path below dir that is not ignored by the .projgenignore file of dir
+// or the default patterns. Ignored directories are skipped entirely.
+func walk(dir string, fn func(path string, info os.FileInfo) error) error {
+	ig, err := LoadIgnore(dir)
+	if err != nil {
+		return err
+	}
+	return filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if path != dir {
+			rel, err := filepath.Rel(dir, path)
+			if err != nil {
+				return err
+			}
+			if ig.Match(filepath.ToSlash(rel), info.IsDir()) {
+				if info.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+		}
+		return fn(path, info)
+	})
+}
@@ -0,0 +1,146 @@
+package filescheck
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// TestIgnoreMatch verifies the gitignore pattern semantics.
+func TestIgnoreMatch(t *testing.T) {
+	ig := ParseIgnore([]string{
+		"# comment",
+		"",
+		"*.swp",
+		"node_modules/",
+		"/README.md",
+		"docs/*.draft",
+		"tests/**",
+		"*.log",
+		"!keep.log",
+		`\#notes`,
+	})
+
+	tests := []struct {
+		path     string
+		isDir    bool
+		expected bool
+	}{
+		{"main.go.swp", false, true},
+		{"src/.main.go.swp", false, true},
+		{"node_modules", true, true},
+		{"web/node_modules", true, true},
+		{"node_modules", false, false},
+		{"README.md", false, true},
+		{"docs/README.md", false, false},
+		{"docs/guide.draft", false, true},
+		{"other/docs/guide.draft", false, false},
+		{"tests/unit/a_test.go", false, true},
+		{"debug.log", false, true},
+		{"keep.log", false, false},
+		{"logs/keep.log", false, false},
+		{"#notes", false, true},
+		{"main.go", false, false},
+	}
+	for _, tt := range tests {
+		if got := ig.Match(tt.path, tt.isDir); got != tt.expected {
+			t.Errorf("Match(%q, %v) = %v; want %v", tt.path, tt.isDir, got, tt.expected)
+		}
+	}
+}
+
+// TestFilesInDirectoriesIgnore verifies that ignored files and directories are not listed.
+func TestFilesInDirectoriesIgnore(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		".projgenignore":          "README.md\n*.swp\nnode_modules/\n",
+		"projgen.yaml":            "name: app\n",
+		"hooks/post-generate.sh":  "#!/bin/sh\n",
+		".git/HEAD":               "ref: refs/heads/main\n",
+		"README.md":               "template readme",
+		"main.go.tmpl":            "package main",
+		".main.go.tmpl.swp":       "swap",
+		"node_modules/a/index.js": "module.exports = {}",
+		"src/app.go":              "package src",
+		"src/projgen.yaml":        "kept, only the root manifest is ignored",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.MkdirAll(filepath.Join(dir, ".git", "refs", "tags"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	found, err := FilesInDirectories(dir)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	var rel []string
+	for _, f := range found {
+		r, _ := filepath.Rel(dir, f)
+		rel = append(rel, filepath.ToSlash(r))
+	}
+	sort.Strings(rel)
+	expected := []string{"main.go.tmpl", "src/app.go", "src/projgen.yaml"}
+	if !reflect.DeepEqual(rel, expected) {
+		t.Errorf("FilesInDirectories() = %v; want %v", rel, expected)
+	}
+
+	templates, err := FindTemplateFiles(dir, ".tmpl")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(templates) != 1 || filepath.Base(templates[0]) != "main.go.tmpl" {
+		t.Errorf("FindTemplateFiles() = %v; want only main.go.tmpl", templates)
+	}
+
+	dirs, err := EmptyDirectories(dir)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(dirs) != 0 {
+		t.Errorf("EmptyDirectories() = %v; want none", dirs)
+	}
+}
+
+// TestHooksDirIgnore verifies that a hooks directory is only ignored in templates using hooks.
+func TestHooksDirIgnore(t *testing.T) {
+	tests := []struct {
+		name     string
+		files    map[string]string
+		expected bool
+	}{
+		{"project directory", map[string]string{"hooks/useEffect.js": "export {}"}, false},
+		{"hook scripts", map[string]string{"hooks/pre-generate.sh": "#!/bin/sh\n"}, true},
+		{"declared hooks", map[string]string{"hooks/check.sh": "#!/bin/sh\n", "projgen.yaml": "hooks:\n  pre:\n    - run: sh hooks/check.sh\n"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for name, content := range tt.files {
+				path := filepath.Join(dir, filepath.FromSlash(name))
+				if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			ig, err := LoadIgnore(dir)
+			if err != nil {
+				t.Fatalf("LoadIgnore returned error: %v", err)
+			}
+			if got := ig.Match("hooks", true); got != tt.expected {
+				t.Errorf("Match(hooks) = %v; want %v", got, tt.expected)
+			}
+		})
+	}
+}
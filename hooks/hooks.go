@@ -0,0 +1,169 @@
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/dirtydriver/projgen/manifest"
+)
+
+// DefaultTimeout applies to hooks that do not declare their own timeout.
+const DefaultTimeout = time.Minute
+
+// Stage selects when a hook runs.
+type Stage string
+
+const (
+	// Pre hooks run before rendering. They can validate the parameters or compute new ones
+	// by printing a JSON object on stdout, which is merged into the parameters.
+	Pre Stage = "pre"
+	// Post hooks run after the project was generated, e.g. to run git init or go mod tidy.
+	Post Stage = "post"
+)
+
+// Hook is a command run in the output directory.
+type Hook struct {
+	// Name identifies the hook in messages.
+	Name string
+	// Command is run by the shell when Script is empty.
+	Command string
+	// Script is the path of an executable from the template's hooks directory.
+	Script  string
+	Timeout time.Duration
+}
+
+// Collect returns the hooks of a stage declared in the manifest followed by the scripts in the
+// hooks directory of each template directory whose names start with "pre-generate" or "post-generate".
+// Directories are visited in the given order (parent templates first), scripts in name order.
+func Collect(templateDirs []string, m *manifest.Manifest, stage Stage) ([]Hook, error) {
+	declared := m.Hooks.Pre
+	if stage == Post {
+		declared = m.Hooks.Post
+	}
+
+	var hooks []Hook
+	for _, h := range declared {
+		timeout := DefaultTimeout
+		if h.Timeout != "" {
+			d, err := time.ParseDuration(h.Timeout)
+			if err != nil {
+				return nil, fmt.Errorf("invalid timeout for hook %q: %w", h.Run, err)
+			}
+			timeout = d
+		}
+		hooks = append(hooks, Hook{Name: h.Run, Command: h.Run, Timeout: timeout})
+	}
+
+	for _, templateDir := range templateDirs {
+		scripts, err := collectScripts(templateDir, stage)
+		if err != nil {
+			return nil, err
+		}
+		hooks = append(hooks, scripts...)
+	}
+	return hooks, nil
+}
+
+// collectScripts returns the hook scripts of a stage in the hooks directory of a template.
+func collectScripts(templateDir string, stage Stage) ([]Hook, error) {
+	entries, err := os.ReadDir(filepath.Join(templateDir, manifest.HooksDir))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasPrefix(entry.Name(), string(stage)+"-generate") {
+			names = append(names, entry.Name())
+		}
+	}
+	sort.Strings(names)
+
+	var hooks []Hook
+	for _, name := range names {
+		script, err := filepath.Abs(filepath.Join(templateDir, manifest.HooksDir, name))
+		if err != nil {
+			return nil, err
+		}
+		hooks = append(hooks, Hook{Name: filepath.Join(filepath.Base(templateDir), manifest.HooksDir, name), Script: script, Timeout: DefaultTimeout})
+	}
+	return hooks, nil
+}
+
+// Run executes the hooks in order in the output directory. Each hook receives the parameters as JSON
+// on stdin and in the PROJGEN_PARAMS environment variable, next to PROJGEN_TEMPLATE_DIR,
+// PROJGEN_OUTPUT_DIR and PROJGEN_HOOK_STAGE. A hook that exits non-zero or exceeds its timeout
+// stops the run with an error. For pre hooks, a JSON object printed on stdout is merged into params
+// and visible to the following hooks; any other output is passed through.
+func Run(hooks []Hook, stage Stage, templateDir, outputDir string, params map[string]interface{}) error {
+	for _, h := range hooks {
+		payload, err := json.Marshal(params)
+		if err != nil {
+			return fmt.Errorf("encoding parameters for hook %s: %w", h.Name, err)
+		}
+
+		stdout, err := run(h, stage, payload, templateDir, outputDir)
+		if err != nil {
+			return err
+		}
+
+		computed := make(map[string]interface{})
+		if stage == Pre && json.Unmarshal(bytes.TrimSpace(stdout), &computed) == nil {
+			for k, v := range computed {
+				params[k] = v
+			}
+			continue
+		}
+		if _, err := os.Stdout.Write(stdout); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func run(h Hook, stage Stage, payload []byte, templateDir, outputDir string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), h.Timeout)
+	defer cancel()
+
+	var cmd *exec.Cmd
+	switch {
+	case h.Script != "":
+		cmd = exec.CommandContext(ctx, h.Script)
+	case runtime.GOOS == "windows":
+		cmd = exec.CommandContext(ctx, "cmd", "/C", h.Command)
+	default:
+		cmd = exec.CommandContext(ctx, "sh", "-c", h.Command)
+	}
+	cmd.Dir = outputDir
+	// Do not wait for children of a killed hook that still hold its output open.
+	cmd.WaitDelay = time.Second
+	cmd.Stdin = bytes.NewReader(payload)
+	cmd.Stderr = os.Stderr
+	cmd.Env = append(os.Environ(),
+		"PROJGEN_PARAMS="+string(payload),
+		"PROJGEN_TEMPLATE_DIR="+templateDir,
+		"PROJGEN_OUTPUT_DIR="+outputDir,
+		"PROJGEN_HOOK_STAGE="+string(stage),
+	)
+
+	stdout, err := cmd.Output()
+	if ctx.Err() == context.DeadlineExceeded {
+		return nil, fmt.Errorf("%s hook %s timed out after %s", stage, h.Name, h.Timeout)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s hook %s failed: %w", stage, h.Name, err)
+	}
+	return stdout, nil
+}
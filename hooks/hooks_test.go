@@ -0,0 +1,97 @@
+package hooks
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dirtydriver/projgen/manifest"
+)
+
+// TestCollect verifies that manifest hooks come first, followed by the matching scripts in name order.
+func TestCollect(t *testing.T) {
+	templateDir := t.TempDir()
+	hooksDir := filepath.Join(templateDir, manifest.HooksDir)
+	if err := os.MkdirAll(hooksDir, 0755); err != nil {
+		t.Fatalf("failed to create hooks directory: %v", err)
+	}
+	for _, name := range []string{"post-generate-20.sh", "post-generate-10.sh", "pre-generate.sh", "helper.sh"} {
+		if err := os.WriteFile(filepath.Join(hooksDir, name), []byte("#!/bin/sh\n"), 0755); err != nil {
+			t.Fatalf("failed to write hook: %v", err)
+		}
+	}
+
+	m := &manifest.Manifest{Hooks: manifest.Hooks{
+		Post: []manifest.Hook{{Run: "git init", Timeout: "5s"}},
+	}}
+	list, err := Collect([]string{templateDir}, m, Post)
+	if err != nil {
+		t.Fatalf("Collect returned error: %v", err)
+	}
+	var names []string
+	for _, h := range list {
+		names = append(names, h.Name)
+	}
+	base := filepath.Base(templateDir)
+	expected := []string{"git init", filepath.Join(base, "hooks", "post-generate-10.sh"), filepath.Join(base, "hooks", "post-generate-20.sh")}
+	if strings.Join(names, ",") != strings.Join(expected, ",") {
+		t.Errorf("Collect() = %v; want %v", names, expected)
+	}
+	if list[0].Timeout != 5*time.Second || list[1].Timeout != DefaultTimeout {
+		t.Errorf("unexpected timeouts: %v, %v", list[0].Timeout, list[1].Timeout)
+	}
+}
+
+// TestRun verifies hook execution, parameter passing and computed parameters.
+func TestRun(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("hook tests use sh")
+	}
+	outputDir := t.TempDir()
+	params := map[string]interface{}{"name": "demo"}
+
+	pre := []Hook{
+		{Name: "compute", Command: `echo '{"slug": "computed"}'`, Timeout: DefaultTimeout},
+		{Name: "check", Command: `grep -q computed && test "$PROJGEN_HOOK_STAGE" = pre`, Timeout: DefaultTimeout},
+	}
+	if err := Run(pre, Pre, "tmpl", outputDir, params); err != nil {
+		t.Fatalf("Run returned error: %v", err)
+	}
+	if params["slug"] != "computed" {
+		t.Errorf("expected computed parameter, got %v", params)
+	}
+
+	post := []Hook{{Name: "touch", Command: `echo "$PROJGEN_PARAMS" > params.json`, Timeout: DefaultTimeout}}
+	if err := Run(post, Post, "tmpl", outputDir, params); err != nil {
+		t.Fatalf("Run returned error: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(outputDir, "params.json"))
+	if err != nil {
+		t.Fatalf("expected hook to run in the output directory: %v", err)
+	}
+	if !strings.Contains(string(data), `"name":"demo"`) {
+		t.Errorf("unexpected parameters passed to hook: %s", data)
+	}
+}
+
+// TestRunErrors verifies that failing and slow hooks abort the run.
+func TestRunErrors(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("hook tests use sh")
+	}
+	outputDir := t.TempDir()
+
+	failing := []Hook{{Name: "fail", Command: "exit 3", Timeout: DefaultTimeout}}
+	if err := Run(failing, Post, "tmpl", outputDir, map[string]interface{}{}); err == nil {
+		t.Error("expected error for failing hook, got nil")
+	}
+
+	slow := []Hook{{Name: "slow", Command: "exec sleep 5", Timeout: 50 * time.Millisecond}}
+	err := Run(slow, Post, "tmpl", outputDir, map[string]interface{}{})
+	if err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Errorf("expected timeout error, got %v", err)
+	}
+}
@@ -0,0 +1,337 @@
+package manifest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dirtydriver/projgen/utils"
+	"sigs.k8s.io/yaml"
+)
+
+// FileName is the name of the manifest file a template type directory may ship.
+const FileName = "projgen.yaml"
+
+// HooksDir is the template directory holding hook scripts. It is not part of the output when the
+// template uses hooks, see UsesHooksDir.
+const HooksDir = "hooks"
+
+// Manifest describes a template type and the parameters it accepts.
+// Priority decides which template wins when several types generated together produce the same file.
+//
+// Example projgen.yaml:
+//
+//	name: maven
+//	description: Maven project skeleton
+//	extends: base
+//	priority: 10
+//	parameters:
+//	  - name: group_id
+//	    description: Maven group id
+//	    required: true
+//	    regex: '[a-z]+(\.[a-z]+)*'
+//	  - name: java_version
+//	    type: int
+//	    default: 21
+//	    enum: [17, 21]
+//	rules:
+//	  - include: Dockerfile
+//	    when: .docker
+//	  - exclude: "db/**"
+//	    when: not .database
+//	modes:
+//	  - path: mvnw
+//	    mode: "0755"
+//	hooks:
+//	  post:
+//	    - run: git init
+//	    - run: go mod tidy
+//	      timeout: 2m
+type Manifest struct {
+	Name        string      `json:"name,omitempty"`
+	Description string      `json:"description,omitempty"`
+	Version     string      `json:"version,omitempty"`
+	Tags        []string    `json:"tags,omitempty"`
+	Extends     StringList  `json:"extends,omitempty"`
+	Priority    int         `json:"priority,omitempty"`
+	Parameters  []Parameter `json:"parameters,omitempty"`
+	Rules       []Rule      `json:"rules,omitempty"`
+	Modes       []FileMode  `json:"modes,omitempty"`
+	Hooks       Hooks       `json:"hooks,omitempty"`
+}
+
+// Hooks lists the commands run before rendering (Pre) and after the project was generated (Post).
+type Hooks struct {
+	Pre  []Hook `json:"pre,omitempty"`
+	Post []Hook `json:"post,omitempty"`
+}
+
+// Hook is a shell command run in the output directory.
+// Timeout is a Go duration such as "30s"; an empty timeout uses the default of the hooks package.
+type Hook struct {
+	Run     string `json:"run"`
+	Timeout string `json:"timeout,omitempty"`
+}
+
+// StringList is a list of strings that may also be written as a single string in YAML.
+type StringList []string
+
+// UnmarshalJSON accepts either a string or a list of strings.
+func (l *StringList) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*l = StringList{single}
+		return nil
+	}
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return fmt.Errorf("expected a string or a list of strings: %w", err)
+	}
+	*l = list
+	return nil
+}
+
+// Rule conditionally includes or excludes template files.
+// Include and Exclude are glob patterns (see utils.MatchGlob) matched against the path relative
+// to the template directory, with or without its .tmpl extension. When is a template pipeline
+// such as '.docker' or 'eq .db "postgres"'; an include rule emits matching files only when it is true,
+// an exclude rule drops matching files when it is true or empty.
+type Rule struct {
+	Include string `json:"include,omitempty"`
+	Exclude string `json:"exclude,omitempty"`
+	When    string `json:"when,omitempty"`
+}
+
+// Matches reports whether the rule applies to the given slash separated template relative path.
+func (r *Rule) Matches(relPath string) bool {
+	pattern := r.Include
+	if pattern == "" {
+		pattern = r.Exclude
+	}
+	return utils.MatchGlob(pattern, relPath) || utils.MatchGlob(pattern, strings.TrimSuffix(relPath, ".tmpl"))
+}
+
+// FileMode sets the permissions of the generated files matching a glob pattern, overriding the
+// mode of the template file. Mode is an octal string such as "0755".
+type FileMode struct {
+	Path string `json:"path"`
+	Mode string `json:"mode"`
+}
+
+// parseMode parses an octal permission string.
+func parseMode(mode string) (os.FileMode, error) {
+	perm, err := strconv.ParseUint(mode, 8, 32)
+	if err != nil || perm > 0777 {
+		return 0, fmt.Errorf("invalid mode %q, expected an octal permission such as \"0755\"", mode)
+	}
+	return os.FileMode(perm), nil
+}
+
+// ModeFor returns the mode the manifest sets for a slash separated template relative path.
+// When several entries match, the last one wins.
+func (m *Manifest) ModeFor(relPath string) (os.FileMode, bool) {
+	var mode os.FileMode
+	found := false
+	for _, fm := range m.Modes {
+		if !utils.MatchGlob(fm.Path, relPath) && !utils.MatchGlob(fm.Path, strings.TrimSuffix(relPath, ".tmpl")) {
+			continue
+		}
+		if perm, err := parseMode(fm.Mode); err == nil {
+			mode, found = perm, true
+		}
+	}
+	return mode, found
+}
+
+// Load reads the manifest from the given template type directory.
+// A directory without a manifest yields an empty, valid Manifest.
+func Load(dir string) (*Manifest, error) {
+	manifestPath := filepath.Join(dir, FileName)
+	data, err := os.ReadFile(manifestPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return &Manifest{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return Parse(data, manifestPath)
+}
+
+// UsesHooksDir reports whether the hooks directory of a template type directory belongs to projgen
+// rather than to the generated project: its manifest m declares hooks, which may call scripts kept
+// there, or the directory holds pre-generate or post-generate scripts.
+func UsesHooksDir(dir string, m *Manifest) (bool, error) {
+	if len(m.Hooks.Pre) > 0 || len(m.Hooks.Post) > 0 {
+		return true, nil
+	}
+	entries, err := os.ReadDir(filepath.Join(dir, HooksDir))
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() && (strings.HasPrefix(entry.Name(), "pre-generate") || strings.HasPrefix(entry.Name(), "post-generate")) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// Parse decodes manifest content and checks that its parameter declarations are well formed.
+// The source is only used to give error messages some context.
+func Parse(data []byte, source string) (*Manifest, error) {
+	var m Manifest
+	if err := yaml.UnmarshalStrict(data, &m); err != nil {
+		return nil, fmt.Errorf("parsing manifest %s: %w", source, err)
+	}
+	if err := m.check(); err != nil {
+		return nil, fmt.Errorf("invalid manifest %s: %w", source, err)
+	}
+	return &m, nil
+}
+
+// check validates the parameter declarations themselves.
+func (m *Manifest) check() error {
+	var errs []error
+	seen := make(map[string]bool)
+	for _, p := range m.Parameters {
+		if p.Name == "" {
+			errs = append(errs, errors.New("parameter without a name"))
+			continue
+		}
+		if seen[p.Name] {
+			errs = append(errs, fmt.Errorf("parameter %s declared more than once", p.Name))
+		}
+		seen[p.Name] = true
+		if _, err := utils.ParsePath(p.Name); err != nil {
+			errs = append(errs, &FieldError{Field: p.Name, Err: fmt.Errorf("invalid name: %w", err)})
+		}
+		if !validType(p.Type) {
+			errs = append(errs, &FieldError{Field: p.Name, Err: fmt.Errorf("unknown type %q", p.Type)})
+		}
+		if p.Regex != "" {
+			if _, err := regexp.Compile(p.Regex); err != nil {
+				errs = append(errs, &FieldError{Field: p.Name, Err: fmt.Errorf("invalid regex: %w", err)})
+			}
+		}
+		if p.Default != nil {
+			if _, err := p.Coerce(p.Default); err != nil {
+				errs = append(errs, &FieldError{Field: p.Name, Err: fmt.Errorf("invalid default: %w", err)})
+			}
+		}
+	}
+	for i, r := range m.Rules {
+		if (r.Include == "") == (r.Exclude == "") {
+			errs = append(errs, fmt.Errorf("rule %d: exactly one of include or exclude must be set", i+1))
+		}
+		if r.Include != "" && r.When == "" {
+			errs = append(errs, fmt.Errorf("rule %d: include %s needs a when condition", i+1, r.Include))
+		}
+	}
+	for i, fm := range m.Modes {
+		if fm.Path == "" {
+			errs = append(errs, fmt.Errorf("mode %d: path must be set", i+1))
+		}
+		if _, err := parseMode(fm.Mode); err != nil {
+			errs = append(errs, fmt.Errorf("mode %d: %w", i+1, err))
+		}
+	}
+	stages := []struct {
+		name  string
+		hooks []Hook
+	}{{"pre", m.Hooks.Pre}, {"post", m.Hooks.Post}}
+	for _, stage := range stages {
+		for i, h := range stage.hooks {
+			if strings.TrimSpace(h.Run) == "" {
+				errs = append(errs, fmt.Errorf("%s hook %d: run must be set", stage.name, i+1))
+			}
+			if h.Timeout != "" {
+				if _, err := time.ParseDuration(h.Timeout); err != nil {
+					errs = append(errs, fmt.Errorf("%s hook %d: invalid timeout: %w", stage.name, i+1, err))
+				}
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Parameter returns the declaration for the named parameter, or nil if it is not declared.
+func (m *Manifest) Parameter(name string) *Parameter {
+	for i := range m.Parameters {
+		if m.Parameters[i].Name == name {
+			return &m.Parameters[i]
+		}
+	}
+	return nil
+}
+
+// RequiredNames returns the names of all parameters declared as required.
+func (m *Manifest) RequiredNames() []string {
+	var names []string
+	for _, p := range m.Parameters {
+		if p.Required {
+			names = append(names, p.Name)
+		}
+	}
+	return names
+}
+
+// SecretNames returns the names of all parameters declared as secret.
+func (m *Manifest) SecretNames() []string {
+	var names []string
+	for _, p := range m.Parameters {
+		if p.Secret {
+			names = append(names, p.Name)
+		}
+	}
+	return names
+}
+
+// Merge combines the manifest of a parent template with the manifest of a template extending it.
+// Metadata set in the child wins, parameter declarations are merged by name with the child
+// overriding the parent, and tags, rules, modes and hooks of both are kept, parent first.
+// The result does not extend anything itself.
+func Merge(parent, child *Manifest) *Manifest {
+	merged := &Manifest{
+		Name:        firstNonEmpty(child.Name, parent.Name),
+		Description: firstNonEmpty(child.Description, parent.Description),
+		Version:     firstNonEmpty(child.Version, parent.Version),
+		Priority:    parent.Priority,
+		Tags:        utils.RemoveDuplicates(append(append([]string{}, parent.Tags...), child.Tags...)),
+		Rules:       append(append([]Rule{}, parent.Rules...), child.Rules...),
+		Modes:       append(append([]FileMode{}, parent.Modes...), child.Modes...),
+		Hooks: Hooks{
+			Pre:  append(append([]Hook{}, parent.Hooks.Pre...), child.Hooks.Pre...),
+			Post: append(append([]Hook{}, parent.Hooks.Post...), child.Hooks.Post...),
+		},
+	}
+	if child.Priority != 0 {
+		merged.Priority = child.Priority
+	}
+	merged.Parameters = append(merged.Parameters, parent.Parameters...)
+	for _, p := range child.Parameters {
+		if existing := merged.Parameter(p.Name); existing != nil {
+			*existing = p
+			continue
+		}
+		merged.Parameters = append(merged.Parameters, p)
+	}
+	return merged
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
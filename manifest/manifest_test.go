@@ -0,0 +1,303 @@
+package manifest
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestLoad verifies that a manifest is read from a template directory.
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	content := `name: maven
+description: Maven project
+parameters:
+  - name: group_id
+    required: true
+    regex: '[a-z]+(\.[a-z]+)*'
+  - name: java_version
+    type: int
+    default: 21
+    enum: [17, 21]
+`
+	if err := os.WriteFile(filepath.Join(dir, FileName), []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write manifest: %v", err)
+	}
+
+	m, err := Load(dir)
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	if m.Name != "maven" || m.Description != "Maven project" {
+		t.Errorf("unexpected manifest metadata: %+v", m)
+	}
+	if len(m.Parameters) != 2 {
+		t.Fatalf("expected 2 parameters, got %d", len(m.Parameters))
+	}
+	if !reflect.DeepEqual(m.RequiredNames(), []string{"group_id"}) {
+		t.Errorf("unexpected required parameters: %v", m.RequiredNames())
+	}
+	if p := m.Parameter("java_version"); p == nil || p.Type != TypeInt {
+		t.Errorf("unexpected java_version declaration: %+v", p)
+	}
+}
+
+// TestLoadMissing verifies that a directory without a manifest yields an empty manifest.
+func TestLoadMissing(t *testing.T) {
+	m, err := Load(t.TempDir())
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	if m == nil || len(m.Parameters) != 0 {
+		t.Errorf("expected empty manifest, got %+v", m)
+	}
+}
+
+// TestParseInvalid verifies that malformed declarations are rejected.
+func TestParseInvalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{"unknown field", "parameters:\n  - name: a\n    typo: true\n"},
+		{"unknown type", "parameters:\n  - name: a\n    type: float\n"},
+		{"bad regex", "parameters:\n  - name: a\n    regex: '('\n"},
+		{"bad default", "parameters:\n  - name: a\n    type: int\n    default: abc\n"},
+		{"duplicate", "parameters:\n  - name: a\n  - name: a\n"},
+		{"missing name", "parameters:\n  - type: int\n"},
+		{"invalid name", "parameters:\n  - name: services[x]\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Parse([]byte(tt.content), "test"); err == nil {
+				t.Errorf("expected error, got nil")
+			}
+		})
+	}
+}
+
+// TestCoerce verifies the conversion of parameter values to the declared types.
+func TestCoerce(t *testing.T) {
+	tests := []struct {
+		name      string
+		paramType string
+		value     interface{}
+		expected  interface{}
+		expectErr bool
+	}{
+		{"string", TypeString, "abc", "abc", false},
+		{"number as string", "", 3.0, "3", false},
+		{"int from string", TypeInt, "42", 42, false},
+		{"int from yaml number", TypeInt, 42.0, 42, false},
+		{"int from fraction", TypeInt, 4.2, nil, true},
+		{"int from text", TypeInt, "forty", nil, true},
+		{"bool from string", TypeBool, "true", true, false},
+		{"bool from text", TypeBool, "maybe", nil, true},
+		{"list from csv", TypeList, "a, b", []interface{}{"a", "b"}, false},
+		{"list from flow", TypeList, "[a, 2]", []interface{}{"a", 2.0}, false},
+		{"list from empty", TypeList, "", []interface{}{}, false},
+		{"map from flow", TypeMap, "{a: 1}", map[string]interface{}{"a": 1.0}, false},
+		{"map from scalar", TypeMap, "abc", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := Parameter{Name: "x", Type: tt.paramType}
+			got, err := p.Coerce(tt.value)
+			if tt.expectErr {
+				if err == nil {
+					t.Errorf("expected error, got %v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Coerce returned error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("Coerce(%v) = %#v; want %#v", tt.value, got, tt.expected)
+			}
+		})
+	}
+}
+
+// TestApplyDefaultsAndValidateParams verifies defaults, coercion and per-field errors.
+func TestApplyDefaultsAndValidateParams(t *testing.T) {
+	m := &Manifest{Parameters: []Parameter{
+		{Name: "project.license", Default: "MIT"},
+		{Name: "replicas", Type: TypeInt, Default: 1.0},
+		{Name: "debug", Type: TypeBool},
+		{Name: "env", Enum: []interface{}{"dev", "prod"}},
+		{Name: "group_id", Regex: `[a-z]+(\.[a-z]+)*`},
+	}}
+
+	params := map[string]interface{}{
+		"project": map[string]interface{}{"name": "demo"},
+		"debug":   "true",
+	}
+	m.ApplyDefaults(params)
+	if err := m.ValidateParams(params); err != nil {
+		t.Fatalf("ValidateParams returned error: %v", err)
+	}
+	expected := map[string]interface{}{
+		"project":  map[string]interface{}{"name": "demo", "license": "MIT"},
+		"replicas": 1,
+		"debug":    true,
+	}
+	if !reflect.DeepEqual(params, expected) {
+		t.Errorf("params = %v; want %v", params, expected)
+	}
+
+	params = map[string]interface{}{
+		"replicas": "many",
+		"env":      "staging",
+		"group_id": "Com.Acme",
+	}
+	err := m.ValidateParams(params)
+	if err == nil {
+		t.Fatal("expected validation errors, got nil")
+	}
+	for _, field := range []string{"replicas", "env", "group_id"} {
+		if !strings.Contains(err.Error(), field+": ") {
+			t.Errorf("expected an error for %s, got %v", field, err)
+		}
+	}
+	var fieldErr *FieldError
+	if !errors.As(err, &fieldErr) {
+		t.Errorf("expected FieldError, got %T", err)
+	}
+}
+
+// TestRules verifies rule parsing and path matching.
+func TestRules(t *testing.T) {
+	m, err := Parse([]byte("rules:\n  - include: Dockerfile\n    when: .docker\n  - exclude: \"ci/**\"\n"), "test")
+	if err != nil {
+		t.Fatalf("Parse returned error: %v", err)
+	}
+	if len(m.Rules) != 2 {
+		t.Fatalf("expected 2 rules, got %d", len(m.Rules))
+	}
+	if !m.Rules[0].Matches("Dockerfile.tmpl") || m.Rules[0].Matches("docs/Dockerfile") {
+		t.Errorf("unexpected matches for include rule %+v", m.Rules[0])
+	}
+	if !m.Rules[1].Matches("ci/jobs/build.yml") || m.Rules[1].Matches("src/ci.go") {
+		t.Errorf("unexpected matches for exclude rule %+v", m.Rules[1])
+	}
+
+	invalid := []string{
+		"rules:\n  - when: .docker\n",
+		"rules:\n  - include: a\n    exclude: b\n    when: .x\n",
+		"rules:\n  - include: Dockerfile\n",
+	}
+	for _, content := range invalid {
+		if _, err := Parse([]byte(content), "test"); err == nil {
+			t.Errorf("expected error for %q, got nil", content)
+		}
+	}
+}
+
+// TestModes verifies file mode overrides.
+func TestModes(t *testing.T) {
+	m, err := Parse([]byte("modes:\n  - path: \"bin/*\"\n    mode: \"0755\"\n  - path: bin/secret\n    mode: \"0600\"\n"), "test")
+	if err != nil {
+		t.Fatalf("Parse returned error: %v", err)
+	}
+	tests := []struct {
+		path     string
+		expected os.FileMode
+		found    bool
+	}{
+		{"bin/run.sh.tmpl", 0755, true},
+		{"bin/secret", 0600, true},
+		{"README.md", 0, false},
+	}
+	for _, tt := range tests {
+		if mode, found := m.ModeFor(tt.path); mode != tt.expected || found != tt.found {
+			t.Errorf("ModeFor(%q) = %v, %v; want %v, %v", tt.path, mode, found, tt.expected, tt.found)
+		}
+	}
+
+	invalid := []string{
+		"modes:\n  - path: a\n    mode: \"rwx\"\n",
+		"modes:\n  - path: a\n    mode: \"01777\"\n",
+		"modes:\n  - mode: \"0755\"\n",
+	}
+	for _, content := range invalid {
+		if _, err := Parse([]byte(content), "test"); err == nil {
+			t.Errorf("expected error for %q, got nil", content)
+		}
+	}
+}
+
+// TestExtends verifies that extends accepts a single name or a list.
+func TestExtends(t *testing.T) {
+	single, err := Parse([]byte("extends: base\n"), "test")
+	if err != nil {
+		t.Fatalf("Parse returned error: %v", err)
+	}
+	if !reflect.DeepEqual([]string(single.Extends), []string{"base"}) {
+		t.Errorf("unexpected extends: %v", single.Extends)
+	}
+	list, err := Parse([]byte("extends: [base, ci]\n"), "test")
+	if err != nil {
+		t.Fatalf("Parse returned error: %v", err)
+	}
+	if !reflect.DeepEqual([]string(list.Extends), []string{"base", "ci"}) {
+		t.Errorf("unexpected extends: %v", list.Extends)
+	}
+	if _, err := Parse([]byte("extends: {a: b}\n"), "test"); err == nil {
+		t.Error("expected error for invalid extends, got nil")
+	}
+}
+
+// TestMerge verifies that child declarations override their parent.
+func TestMerge(t *testing.T) {
+	parent := &Manifest{
+		Name:        "base",
+		Description: "Base template",
+		Tags:        []string{"base"},
+		Parameters: []Parameter{
+			{Name: "license", Default: "MIT"},
+			{Name: "name", Required: true},
+		},
+		Rules: []Rule{{Exclude: "tmp/**"}},
+		Hooks: Hooks{Post: []Hook{{Run: "git init"}}},
+	}
+	child := &Manifest{
+		Name:    "maven",
+		Extends: StringList{"base"},
+		Tags:    []string{"java", "base"},
+		Parameters: []Parameter{
+			{Name: "license", Default: "Apache-2.0"},
+			{Name: "group_id"},
+		},
+		Hooks: Hooks{Post: []Hook{{Run: "mvn verify"}}},
+	}
+
+	merged := Merge(parent, child)
+	if merged.Name != "maven" || merged.Description != "Base template" {
+		t.Errorf("unexpected metadata: %+v", merged)
+	}
+	if !reflect.DeepEqual(merged.Tags, []string{"base", "java"}) {
+		t.Errorf("unexpected tags: %v", merged.Tags)
+	}
+	var names []string
+	for _, p := range merged.Parameters {
+		names = append(names, p.Name)
+	}
+	if !reflect.DeepEqual(names, []string{"license", "name", "group_id"}) {
+		t.Errorf("unexpected parameters: %v", names)
+	}
+	if merged.Parameter("license").Default != "Apache-2.0" {
+		t.Errorf("expected child default to win, got %v", merged.Parameter("license").Default)
+	}
+	if len(merged.Rules) != 1 || len(merged.Hooks.Post) != 2 || merged.Hooks.Post[0].Run != "git init" {
+		t.Errorf("unexpected rules or hooks: %+v %+v", merged.Rules, merged.Hooks)
+	}
+	if len(merged.Extends) != 0 {
+		t.Errorf("merged manifest should not extend anything, got %v", merged.Extends)
+	}
+}
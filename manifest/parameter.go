@@ -0,0 +1,219 @@
+package manifest
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/dirtydriver/projgen/utils"
+	"sigs.k8s.io/yaml"
+)
+
+// Supported parameter types. An empty type is treated as TypeString.
+const (
+	TypeString = "string"
+	TypeInt    = "int"
+	TypeBool   = "bool"
+	TypeList   = "list"
+	TypeMap    = "map"
+)
+
+// Parameter declares a single template parameter. Nested parameters use dot notation (e.g. 'project.name').
+type Parameter struct {
+	Name        string        `json:"name"`
+	Type        string        `json:"type,omitempty"`
+	Default     interface{}   `json:"default,omitempty"`
+	Description string        `json:"description,omitempty"`
+	Required    bool          `json:"required,omitempty"`
+	Enum        []interface{} `json:"enum,omitempty"`
+	Regex       string        `json:"regex,omitempty"`
+	// Secret parameters, such as passwords and tokens, are not recorded in the generated project.
+	Secret bool `json:"secret,omitempty"`
+}
+
+// FieldError reports a problem with a single parameter.
+type FieldError struct {
+	Field string
+	Err   error
+}
+
+func (e *FieldError) Error() string {
+	return e.Field + ": " + e.Err.Error()
+}
+
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+func validType(t string) bool {
+	switch t {
+	case "", TypeString, TypeInt, TypeBool, TypeList, TypeMap:
+		return true
+	}
+	return false
+}
+
+// Coerce converts value to the declared type of the parameter.
+// Strings, as passed via --parameter, are parsed into ints, bools, lists and maps;
+// lists may be given either as YAML flow sequences ('[a, b]') or comma separated ('a,b').
+func (p *Parameter) Coerce(value interface{}) (interface{}, error) {
+	switch p.Type {
+	case "", TypeString:
+		switch v := value.(type) {
+		case string:
+			return v, nil
+		case bool, int, int64, float64:
+			return fmt.Sprint(v), nil
+		}
+	case TypeInt:
+		switch v := value.(type) {
+		case int:
+			return v, nil
+		case int64:
+			return int(v), nil
+		case float64:
+			if v == math.Trunc(v) {
+				return int(v), nil
+			}
+		case string:
+			i, err := strconv.Atoi(strings.TrimSpace(v))
+			if err == nil {
+				return i, nil
+			}
+		}
+	case TypeBool:
+		switch v := value.(type) {
+		case bool:
+			return v, nil
+		case string:
+			b, err := strconv.ParseBool(strings.TrimSpace(v))
+			if err == nil {
+				return b, nil
+			}
+		}
+	case TypeList:
+		switch v := value.(type) {
+		case []interface{}:
+			return v, nil
+		case []string:
+			list := make([]interface{}, len(v))
+			for i, item := range v {
+				list[i] = item
+			}
+			return list, nil
+		case string:
+			return parseList(v)
+		}
+	case TypeMap:
+		switch v := value.(type) {
+		case map[string]interface{}:
+			return v, nil
+		case string:
+			var m map[string]interface{}
+			if err := yaml.Unmarshal([]byte(v), &m); err == nil && m != nil {
+				return m, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("cannot use %v (%T) as %s", value, value, p.typeName())
+}
+
+func (p *Parameter) typeName() string {
+	if p.Type == "" {
+		return TypeString
+	}
+	return p.Type
+}
+
+func parseList(s string) (interface{}, error) {
+	s = strings.TrimSpace(s)
+	if strings.HasPrefix(s, "[") {
+		var list []interface{}
+		if err := yaml.Unmarshal([]byte(s), &list); err != nil {
+			return nil, fmt.Errorf("invalid list %q: %w", s, err)
+		}
+		return list, nil
+	}
+	list := []interface{}{}
+	if s == "" {
+		return list, nil
+	}
+	for _, item := range strings.Split(s, ",") {
+		list = append(list, strings.TrimSpace(item))
+	}
+	return list, nil
+}
+
+// Validate checks an already coerced value against the enum and regex constraints of the parameter.
+func (p *Parameter) Validate(value interface{}) error {
+	if len(p.Enum) > 0 {
+		allowed := false
+		for _, e := range p.Enum {
+			if fmt.Sprint(e) == fmt.Sprint(value) {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return fmt.Errorf("value %v is not one of %v", value, p.Enum)
+		}
+	}
+	if p.Regex != "" {
+		s, ok := value.(string)
+		if !ok {
+			s = fmt.Sprint(value)
+		}
+		re, err := regexp.Compile("^(?:" + p.Regex + ")$")
+		if err != nil {
+			return fmt.Errorf("invalid regex: %w", err)
+		}
+		if !re.MatchString(s) {
+			return fmt.Errorf("value %q does not match %s", s, p.Regex)
+		}
+	}
+	return nil
+}
+
+// ApplyDefaults sets the declared default of every parameter that is missing from params.
+func (m *Manifest) ApplyDefaults(params map[string]interface{}) {
+	for _, p := range m.Parameters {
+		if p.Default == nil {
+			continue
+		}
+		if _, ok := utils.LookupKey(params, p.Name); ok {
+			continue
+		}
+		value, err := p.Coerce(p.Default)
+		if err != nil {
+			value = p.Default
+		}
+		utils.SetKey(params, p.Name, value)
+	}
+}
+
+// ValidateParams coerces every declared parameter present in params to its declared type, in place,
+// and validates it. All problems are returned together as FieldErrors joined with errors.Join.
+func (m *Manifest) ValidateParams(params map[string]interface{}) error {
+	var errs []error
+	for i := range m.Parameters {
+		p := &m.Parameters[i]
+		value, ok := utils.LookupKey(params, p.Name)
+		if !ok {
+			continue
+		}
+		coerced, err := p.Coerce(value)
+		if err != nil {
+			errs = append(errs, &FieldError{Field: p.Name, Err: err})
+			continue
+		}
+		if err := p.Validate(coerced); err != nil {
+			errs = append(errs, &FieldError{Field: p.Name, Err: err})
+			continue
+		}
+		utils.SetKey(params, p.Name, coerced)
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,200 @@
+package merge
+
+import (
+	"bytes"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// SplitLines splits content into lines, each keeping its line ending.
+func SplitLines(content []byte) []string {
+	if len(content) == 0 {
+		return nil
+	}
+	lines := strings.SplitAfter(string(content), "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// IsBinary reports whether content looks like a binary file, which cannot be merged line by line.
+func IsBinary(content []byte) bool {
+	return bytes.IndexByte(content[:min(len(content), 8000)], 0) >= 0
+}
+
+// matches returns the index pairs of the lines a and b have in common along a shortest edit
+// script, in increasing order. It uses Myers' O(ND) difference algorithm.
+func matches(a, b []string) [][2]int {
+	// Common prefixes and suffixes match trivially and keep the search small.
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	var pairs [][2]int
+	for i := 0; i < prefix; i++ {
+		pairs = append(pairs, [2]int{i, i})
+	}
+	for _, p := range myers(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]) {
+		pairs = append(pairs, [2]int{p[0] + prefix, p[1] + prefix})
+	}
+	for i := suffix; i > 0; i-- {
+		pairs = append(pairs, [2]int{len(a) - i, len(b) - i})
+	}
+	return pairs
+}
+
+func myers(a, b []string) [][2]int {
+	n, m := len(a), len(b)
+	if n == 0 || m == 0 {
+		return nil
+	}
+	maxD := n + m
+	offset := maxD + 1
+	v := make([]int, 2*maxD+3)
+	// trace[d] holds the furthest reaching x of diagonals -d-1..d+1 before step d.
+	var trace [][]int
+
+search:
+	for d := 0; d <= maxD; d++ {
+		trace = append(trace, slices.Clone(v[offset-d-1:offset+d+2]))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				break search
+			}
+		}
+	}
+
+	// Walk the trace backwards, collecting the diagonal moves.
+	var pairs [][2]int
+	x, y := n, m
+	for d := len(trace) - 1; d >= 0; d-- {
+		w := trace[d]
+		at := func(k int) int { return w[k+d+1] }
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && at(k-1) < at(k+1)) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := at(prevK)
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			x--
+			y--
+			pairs = append(pairs, [2]int{x, y})
+		}
+		x, y = prevX, prevY
+	}
+	slices.Reverse(pairs)
+	return pairs
+}
+
+// diffLine is one line of a line diff: ' ' for a common line, '-' for a line only in a, '+' for a line only in b.
+type diffLine struct {
+	kind byte
+	text string
+}
+
+// Unified returns a unified diff from a to b with three lines of context, or "" if they are equal.
+func Unified(a, b []byte, nameA, nameB string) string {
+	al, bl := SplitLines(a), SplitLines(b)
+	var lines []diffLine
+	i, j := 0, 0
+	for _, p := range append(matches(al, bl), [2]int{len(al), len(bl)}) {
+		for ; i < p[0]; i++ {
+			lines = append(lines, diffLine{'-', al[i]})
+		}
+		for ; j < p[1]; j++ {
+			lines = append(lines, diffLine{'+', bl[j]})
+		}
+		if i < len(al) {
+			lines = append(lines, diffLine{' ', al[i]})
+			i, j = i+1, j+1
+		}
+	}
+
+	const context = 3
+	var out strings.Builder
+	aLine, bLine := 1, 1
+	for start := 0; start < len(lines); {
+		// Skip to the next change.
+		for start < len(lines) && lines[start].kind == ' ' {
+			aLine, bLine = aLine+1, bLine+1
+			start++
+		}
+		if start == len(lines) {
+			break
+		}
+		// Extend the hunk while changes are separated by at most twice the context.
+		end := start
+		for k := start; k < len(lines) && k-end <= 2*context; k++ {
+			if lines[k].kind != ' ' {
+				end = k + 1
+			}
+		}
+		from, to := max(start-context, 0), min(end+context, len(lines))
+		aStart, bStart := aLine-(start-from), bLine-(start-from)
+		var hunk strings.Builder
+		aCount, bCount := 0, 0
+		for _, l := range lines[from:to] {
+			if l.kind != '+' {
+				aCount++
+			}
+			if l.kind != '-' {
+				bCount++
+			}
+			hunk.WriteByte(l.kind)
+			hunk.WriteString(l.text)
+			if !strings.HasSuffix(l.text, "\n") {
+				hunk.WriteString("\n\\ No newline at end of file\n")
+			}
+		}
+		if out.Len() == 0 {
+			fmt.Fprintf(&out, "--- %s\n+++ %s\n", nameA, nameB)
+		}
+		fmt.Fprintf(&out, "@@ -%s +%s @@\n", hunkRange(aStart, aCount), hunkRange(bStart, bCount))
+		out.WriteString(hunk.String())
+
+		for _, l := range lines[start:to] {
+			if l.kind != '+' {
+				aLine++
+			}
+			if l.kind != '-' {
+				bLine++
+			}
+		}
+		start = to
+	}
+	return out.String()
+}
+
+// hunkRange formats the start and length of a hunk; an empty range starts at the line before it.
+func hunkRange(start, count int) string {
+	if count == 0 {
+		start--
+	}
+	if count == 1 {
+		return fmt.Sprint(start)
+	}
+	return fmt.Sprintf("%d,%d", start, count)
+}
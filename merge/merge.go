@@ -0,0 +1,86 @@
+// Package merge implements line based three-way merging of text files.
+package merge
+
+import (
+	"slices"
+	"strings"
+)
+
+// Merge combines the changes from base to ours and from base to theirs.
+// Regions changed on only one side take that side's version; regions changed differently on both
+// sides are written with conflict markers labelled with oursLabel and theirsLabel.
+// It returns the merged content and the number of conflicting regions.
+func Merge(base, ours, theirs []byte, oursLabel, theirsLabel string) ([]byte, int) {
+	b, o, t := SplitLines(base), SplitLines(ours), SplitLines(theirs)
+	inOurs := matchIndex(len(b), matches(b, o))
+	inTheirs := matchIndex(len(b), matches(b, t))
+
+	var out strings.Builder
+	conflicts := 0
+	bi, oi, ti := 0, 0, 0
+	// resolve writes the region between the current positions and the given ends.
+	resolve := func(bEnd, oEnd, tEnd int) {
+		baseChunk, oursChunk, theirsChunk := b[bi:bEnd], o[oi:oEnd], t[ti:tEnd]
+		switch {
+		case slices.Equal(oursChunk, baseChunk):
+			writeLines(&out, theirsChunk)
+		case slices.Equal(theirsChunk, baseChunk), slices.Equal(oursChunk, theirsChunk):
+			writeLines(&out, oursChunk)
+		default:
+			conflicts++
+			out.WriteString("<<<<<<< " + oursLabel + "\n")
+			writeLines(&out, terminated(oursChunk))
+			out.WriteString("=======\n")
+			writeLines(&out, terminated(theirsChunk))
+			out.WriteString(">>>>>>> " + theirsLabel + "\n")
+		}
+	}
+
+	for {
+		// Copy the lines that are unchanged on both sides.
+		for bi < len(b) && inOurs[bi] == oi && inTheirs[bi] == ti {
+			out.WriteString(b[bi])
+			bi, oi, ti = bi+1, oi+1, ti+1
+		}
+		// Find the next base line that both sides kept; everything before it changed on at least one side.
+		next := bi
+		for next < len(b) && (inOurs[next] < 0 || inTheirs[next] < 0) {
+			next++
+		}
+		if next == len(b) {
+			resolve(len(b), len(o), len(t))
+			break
+		}
+		resolve(next, inOurs[next], inTheirs[next])
+		bi, oi, ti = next, inOurs[next], inTheirs[next]
+	}
+	return []byte(out.String()), conflicts
+}
+
+// matchIndex maps every base line to the index of its matching line, or -1 if it has none.
+func matchIndex(n int, pairs [][2]int) []int {
+	index := make([]int, n)
+	for i := range index {
+		index[i] = -1
+	}
+	for _, p := range pairs {
+		index[p[0]] = p[1]
+	}
+	return index
+}
+
+func writeLines(out *strings.Builder, lines []string) {
+	for _, line := range lines {
+		out.WriteString(line)
+	}
+}
+
+// terminated makes sure the last line ends with a newline so that a conflict marker starts on its own line.
+func terminated(lines []string) []string {
+	if len(lines) == 0 || strings.HasSuffix(lines[len(lines)-1], "\n") {
+		return lines
+	}
+	lines = slices.Clone(lines)
+	lines[len(lines)-1] += "\n"
+	return lines
+}
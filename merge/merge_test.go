@@ -0,0 +1,151 @@
+package merge
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitLines(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{"", nil},
+		{"a", []string{"a"}},
+		{"a\nb\n", []string{"a\n", "b\n"}},
+		{"a\n\nb", []string{"a\n", "\n", "b"}},
+	}
+	for _, tt := range tests {
+		if got := SplitLines([]byte(tt.input)); !reflect.DeepEqual(got, tt.expected) {
+			t.Errorf("SplitLines(%q) = %q; want %q", tt.input, got, tt.expected)
+		}
+	}
+}
+
+func TestMatches(t *testing.T) {
+	a := []string{"a", "b", "c", "a", "b", "b", "a"}
+	b := []string{"c", "b", "a", "b", "a", "c"}
+	pairs := matches(a, b)
+	// The shortest edit script between these sequences keeps four lines.
+	if len(pairs) != 4 {
+		t.Fatalf("matches() = %v; want 4 pairs", pairs)
+	}
+	for i, p := range pairs {
+		if a[p[0]] != b[p[1]] {
+			t.Errorf("pair %v matches different lines", p)
+		}
+		if i > 0 && (p[0] <= pairs[i-1][0] || p[1] <= pairs[i-1][1]) {
+			t.Errorf("pairs are not increasing: %v", pairs)
+		}
+	}
+}
+
+func TestMerge(t *testing.T) {
+	tests := []struct {
+		name      string
+		base      string
+		ours      string
+		theirs    string
+		expected  string
+		conflicts int
+	}{
+		{
+			name:     "unchanged",
+			base:     "a\nb\nc\n",
+			ours:     "a\nb\nc\n",
+			theirs:   "a\nb\nc\n",
+			expected: "a\nb\nc\n",
+		},
+		{
+			name:     "changes in different places",
+			base:     "a\nb\nc\nd\ne\n",
+			ours:     "a\nB\nc\nd\ne\n",
+			theirs:   "a\nb\nc\nd\nE\nf\n",
+			expected: "a\nB\nc\nd\nE\nf\n",
+		},
+		{
+			name:     "same change on both sides",
+			base:     "a\nb\nc\n",
+			ours:     "a\nx\nc\n",
+			theirs:   "a\nx\nc\n",
+			expected: "a\nx\nc\n",
+		},
+		{
+			name:     "deletion and addition",
+			base:     "a\nb\nc\n",
+			ours:     "a\nc\n",
+			theirs:   "z\na\nb\nc\n",
+			expected: "z\na\nc\n",
+		},
+		{
+			name:      "conflict",
+			base:      "a\nb\nc\n",
+			ours:      "a\nmine\nc\n",
+			theirs:    "a\ntheirs\nc\n",
+			expected:  "a\n<<<<<<< current\nmine\n=======\ntheirs\n>>>>>>> template\nc\n",
+			conflicts: 1,
+		},
+		{
+			name:      "conflict without trailing newline",
+			base:      "a",
+			ours:      "b",
+			theirs:    "c",
+			expected:  "<<<<<<< current\nb\n=======\nc\n>>>>>>> template\n",
+			conflicts: 1,
+		},
+		{
+			name:      "both added",
+			base:      "",
+			ours:      "x\n",
+			theirs:    "y\n",
+			expected:  "<<<<<<< current\nx\n=======\ny\n>>>>>>> template\n",
+			conflicts: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			merged, conflicts := Merge([]byte(tt.base), []byte(tt.ours), []byte(tt.theirs), "current", "template")
+			if string(merged) != tt.expected {
+				t.Errorf("Merge() = %q; want %q", merged, tt.expected)
+			}
+			if conflicts != tt.conflicts {
+				t.Errorf("Merge() conflicts = %d; want %d", conflicts, tt.conflicts)
+			}
+		})
+	}
+}
+
+func TestIsBinary(t *testing.T) {
+	if IsBinary([]byte("plain text\n")) {
+		t.Error("IsBinary(text) = true")
+	}
+	if !IsBinary([]byte{0x89, 'P', 'N', 'G', 0x00}) {
+		t.Error("IsBinary(png) = false")
+	}
+}
+
+func TestUnified(t *testing.T) {
+	if diff := Unified([]byte("a\nb\n"), []byte("a\nb\n"), "a", "b"); diff != "" {
+		t.Errorf("Unified(equal) = %q; want empty", diff)
+	}
+
+	a := "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n13\n14\n15\n"
+	b := "1\n2\nthree\n4\n5\n6\n7\n8\n9\n10\n11\n12\n13\n14\n15\n16\n"
+	expected := "--- a/file\n+++ b/file\n" +
+		"@@ -1,6 +1,6 @@\n 1\n 2\n-3\n+three\n 4\n 5\n 6\n" +
+		"@@ -13,3 +13,4 @@\n 13\n 14\n 15\n+16\n"
+	if diff := Unified([]byte(a), []byte(b), "a/file", "b/file"); diff != expected {
+		t.Errorf("Unified() = %q; want %q", diff, expected)
+	}
+
+	expected = "--- a\n+++ b\n@@ -1 +1 @@\n-x\n\\ No newline at end of file\n+y\n"
+	if diff := Unified([]byte("x"), []byte("y\n"), "a", "b"); diff != expected {
+		t.Errorf("Unified() = %q; want %q", diff, expected)
+	}
+
+	expected = "--- a\n+++ b\n@@ -0,0 +1,2 @@\n+x\n+y\n"
+	if diff := Unified(nil, []byte("x\ny\n"), "a", "b"); diff != expected {
+		t.Errorf("Unified() = %q; want %q", diff, expected)
+	}
+}
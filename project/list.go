@@ -0,0 +1,103 @@
+package project
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/dirtydriver/projgen/manifest"
+	"github.com/dirtydriver/projgen/utils"
+)
+
+// Info summarizes a template type found in a template root.
+type Info struct {
+	// Type is the name of the template directory, i.e. the value passed to --type.
+	Type        string   `json:"type"`
+	Name        string   `json:"name,omitempty"`
+	Description string   `json:"description,omitempty"`
+	Version     string   `json:"version,omitempty"`
+	Tags        []string `json:"tags,omitempty"`
+	Extends     []string `json:"extends,omitempty"`
+}
+
+// TemplateTypes returns the names of the template type directories in a template root,
+// sorted by name. Hidden directories are not template types.
+func TemplateTypes(root string) ([]string, error) {
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return nil, err
+	}
+	var types []string
+	for _, entry := range entries {
+		if entry.IsDir() && !strings.HasPrefix(entry.Name(), ".") {
+			types = append(types, entry.Name())
+		}
+	}
+	sort.Strings(types)
+	return types, nil
+}
+
+// ListTemplates describes every template type in a template root using its manifest.
+func ListTemplates(root string) ([]Info, error) {
+	types, err := TemplateTypes(root)
+	if err != nil {
+		return nil, err
+	}
+	infos := make([]Info, 0, len(types))
+	for _, t := range types {
+		m, err := manifest.Load(filepath.Join(root, t))
+		if err != nil {
+			return nil, err
+		}
+		infos = append(infos, Info{
+			Type:        t,
+			Name:        m.Name,
+			Description: m.Description,
+			Version:     m.Version,
+			Tags:        m.Tags,
+			Extends:     m.Extends,
+		})
+	}
+	return infos, nil
+}
+
+// UnknownTypeError reports a template type that does not exist in the template root.
+type UnknownTypeError struct {
+	Type string
+	// Suggestion is the most similar existing type, if any.
+	Suggestion string
+	// Available lists all existing types.
+	Available []string
+}
+
+func (e *UnknownTypeError) Error() string {
+	msg := fmt.Sprintf("unknown type %q", e.Type)
+	if e.Suggestion != "" {
+		msg += fmt.Sprintf(", did you mean %q?", e.Suggestion)
+	}
+	if len(e.Available) == 0 {
+		return msg + " (no template types found)"
+	}
+	return msg + " Available types: " + strings.Join(e.Available, ", ")
+}
+
+// LoadType loads the template of the given type from a template root.
+// An unknown type yields an UnknownTypeError suggesting the closest existing type.
+func LoadType(root, templateType string) (*Template, error) {
+	types, err := TemplateTypes(root)
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range types {
+		if t == templateType {
+			return LoadTemplate(filepath.Join(root, templateType))
+		}
+	}
+	return nil, &UnknownTypeError{
+		Type:       templateType,
+		Suggestion: utils.ClosestMatch(templateType, types),
+		Available:  types,
+	}
+}
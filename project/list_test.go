@@ -0,0 +1,55 @@
+package project
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestListTemplates verifies that template types are described by their manifests.
+func TestListTemplates(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"maven/projgen.yaml": "name: Maven\ndescription: Maven project\nversion: 1.2.0\ntags: [java]\nextends: base\n",
+		"base/LICENSE":       "MIT",
+		".git/config":        "",
+	})
+
+	infos, err := ListTemplates(root)
+	if err != nil {
+		t.Fatalf("ListTemplates returned error: %v", err)
+	}
+	expected := []Info{
+		{Type: "base"},
+		{Type: "maven", Name: "Maven", Description: "Maven project", Version: "1.2.0", Tags: []string{"java"}, Extends: []string{"base"}},
+	}
+	if !reflect.DeepEqual(infos, expected) {
+		t.Errorf("ListTemplates() = %+v; want %+v", infos, expected)
+	}
+}
+
+// TestLoadTypeUnknown verifies the suggestion for a mistyped template type.
+func TestLoadTypeUnknown(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"maven/pom.xml":       "",
+		"gradle/build.gradle": "",
+	})
+
+	if _, err := LoadType(root, "maven"); err != nil {
+		t.Fatalf("LoadType returned error: %v", err)
+	}
+
+	_, err := LoadType(root, "mavne")
+	var unknown *UnknownTypeError
+	if !errors.As(err, &unknown) {
+		t.Fatalf("expected UnknownTypeError, got %v", err)
+	}
+	if unknown.Suggestion != "maven" || !reflect.DeepEqual(unknown.Available, []string{"gradle", "maven"}) {
+		t.Errorf("unexpected error details: %+v", unknown)
+	}
+	if !strings.Contains(err.Error(), `did you mean "maven"?`) {
+		t.Errorf("unexpected error message: %v", err)
+	}
+}
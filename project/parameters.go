@@ -0,0 +1,124 @@
+package project
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/dirtydriver/projgen/manifest"
+	"github.com/dirtydriver/projgen/templater"
+)
+
+// ParameterInfo describes a parameter of a template, combining its manifest declaration with how
+// the template files use it.
+type ParameterInfo struct {
+	// Path is the parameter in dot notation, with [] for the elements of lists, e.g. "items[].name".
+	Path string `json:"path"`
+	// Type is the declared type, or the type inferred from the templates if none is declared.
+	Type     string `json:"type,omitempty"`
+	Required bool   `json:"required"`
+	// RequiredWhen names the parameter guarding the blocks the parameter is used in: it is only
+	// required when that parameter is set.
+	RequiredWhen string `json:"requiredWhen,omitempty"`
+	// RequiredUnless names the parameter whose {{ else }} branches the parameter is used in: it is
+	// only required when that parameter is not set.
+	RequiredUnless string `json:"requiredUnless,omitempty"`
+	// Usage is how the templates use the parameter, see templater.Parameter; it is empty for
+	// parameters that are only declared.
+	Usage       string        `json:"usage,omitempty"`
+	Default     interface{}   `json:"default,omitempty"`
+	Description string        `json:"description,omitempty"`
+	Enum        []interface{} `json:"enum,omitempty"`
+	Regex       string        `json:"regex,omitempty"`
+	Secret      bool          `json:"secret,omitempty"`
+	// Locations lists the file:line places the parameter is used, relative to the template root.
+	Locations []string `json:"locations,omitempty"`
+}
+
+// DescribeParameters describes the parameters used by the template files, followed by those only
+// declared in the manifest. A default or requirement declared in the manifest takes precedence over
+// the templates.
+func DescribeParameters(used []templater.Parameter, m *manifest.Manifest, root string) []ParameterInfo {
+	var infos []ParameterInfo
+	seen := make(map[string]bool)
+	for _, p := range used {
+		seen[p.Name] = true
+		infos = append(infos, describe(p, m.Parameter(p.Name), used, root))
+	}
+	for _, decl := range m.Parameters {
+		if !seen[decl.Name] {
+			infos = append(infos, describe(templater.Parameter{Name: decl.Name}, &decl, used, root))
+		}
+	}
+	return infos
+}
+
+func describe(p templater.Parameter, decl *manifest.Parameter, used []templater.Parameter, root string) ParameterInfo {
+	info := ParameterInfo{
+		Path:    p.Name,
+		Type:    inferType(p, used),
+		Usage:   p.Usage,
+		Default: p.Default,
+	}
+	if p.Usage == templater.UsageRequired {
+		info.Required = p.Within == ""
+		if p.Unless {
+			info.RequiredUnless = p.Within
+		} else {
+			info.RequiredWhen = p.Within
+		}
+	}
+	if decl != nil {
+		if decl.Type != "" || info.Type == "" {
+			info.Type = manifestType(decl.Type)
+		}
+		if decl.Default != nil {
+			info.Default, info.Required, info.RequiredWhen, info.RequiredUnless = decl.Default, false, "", ""
+		}
+		if decl.Required {
+			info.Required, info.RequiredWhen, info.RequiredUnless = true, "", ""
+		}
+		info.Description, info.Enum, info.Regex, info.Secret = decl.Description, decl.Enum, decl.Regex, decl.Secret
+	}
+	for _, l := range p.Locations {
+		if rel, err := filepath.Rel(root, l.File); err == nil {
+			l.File = rel
+		}
+		l.File = filepath.ToSlash(l.File)
+		info.Locations = append(info.Locations, l.String())
+	}
+	return info
+}
+
+// inferType guesses the type of a parameter from the templates: parameters with fields are maps,
+// parameters whose elements or indices are used or that are ranged over are lists, and a literal
+// default tells the type of optional ones. A manifest declaring a map overrides the list.
+func inferType(p templater.Parameter, used []templater.Parameter) string {
+	for _, other := range used {
+		switch {
+		case strings.HasPrefix(other.Name, p.Name+"["):
+			return manifest.TypeList
+		case strings.HasPrefix(other.Name, p.Name+"."):
+			return manifest.TypeMap
+		}
+	}
+	if p.Ranged {
+		return manifest.TypeList
+	}
+	switch p.Default.(type) {
+	case string:
+		return manifest.TypeString
+	case int:
+		return manifest.TypeInt
+	case bool:
+		return manifest.TypeBool
+	}
+	return ""
+}
+
+// manifestType returns the declared type, an empty type meaning a string.
+func manifestType(t string) string {
+	if t == "" {
+		return manifest.TypeString
+	}
+	return t
+}
@@ -0,0 +1,50 @@
+package project
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/dirtydriver/projgen/manifest"
+	"github.com/dirtydriver/projgen/templater"
+)
+
+func TestDescribeParameters(t *testing.T) {
+	root := "templates"
+	file := filepath.Join(root, "app", "README.md.tmpl")
+	used := []templater.Parameter{
+		{Name: "db", Usage: templater.UsageGuard, Locations: []templater.Location{{File: file, Line: 1}}},
+		{Name: "deps", Usage: templater.UsageGuard, Ranged: true},
+		{Name: "env", Usage: templater.UsageGuard, Ranged: true},
+		{Name: "fallback", Usage: templater.UsageRequired, Within: "docker", Unless: true},
+		{Name: "image", Usage: templater.UsageRequired, Within: "docker", Locations: []templater.Location{{File: file, Line: 2}, {File: file, Line: 5}}},
+		{Name: "license", Usage: templater.UsageOptional, Default: "MIT"},
+		{Name: "name", Usage: templater.UsageRequired},
+		{Name: "port", Usage: templater.UsageRequired},
+		{Name: "project", Usage: templater.UsageGuard},
+		{Name: "project.id", Usage: templater.UsageRequired, Within: "project"},
+	}
+	m := &manifest.Manifest{Parameters: []manifest.Parameter{
+		{Name: "port", Type: manifest.TypeInt, Default: 8080, Description: "HTTP port"},
+		{Name: "db", Required: true, Enum: []interface{}{"pg", "mysql"}},
+		{Name: "token", Secret: true},
+		{Name: "env", Type: manifest.TypeMap},
+	}}
+
+	expected := []ParameterInfo{
+		{Path: "db", Type: "string", Required: true, Usage: "guard", Enum: []interface{}{"pg", "mysql"}, Locations: []string{"app/README.md.tmpl:1"}},
+		{Path: "deps", Type: "list", Usage: "guard"},
+		{Path: "env", Type: "map", Usage: "guard"},
+		{Path: "fallback", RequiredUnless: "docker", Usage: "required"},
+		{Path: "image", RequiredWhen: "docker", Usage: "required", Locations: []string{"app/README.md.tmpl:2", "app/README.md.tmpl:5"}},
+		{Path: "license", Type: "string", Usage: "optional", Default: "MIT"},
+		{Path: "name", Required: true, Usage: "required"},
+		{Path: "port", Type: "int", Usage: "required", Default: 8080, Description: "HTTP port"},
+		{Path: "project", Type: "map", Usage: "guard"},
+		{Path: "project.id", RequiredWhen: "project", Usage: "required"},
+		{Path: "token", Type: "string", Secret: true},
+	}
+	if got := DescribeParameters(used, m, root); !reflect.DeepEqual(got, expected) {
+		t.Errorf("DescribeParameters() =\n%+v\nwant\n%+v", got, expected)
+	}
+}
@@ -0,0 +1,174 @@
+package project
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/dirtydriver/projgen/templater"
+)
+
+// Reasons why a planned entry is skipped.
+const (
+	SkipExcluded     = "excluded by rule"
+	SkipEmptySegment = "empty path segment"
+)
+
+// Entry is a file the generation writes.
+type Entry struct {
+	// Template is the name of the template type providing the file.
+	Template string
+	// Priority is the priority of that template, see manifest.Manifest.
+	Priority int
+	// File is the template file.
+	File File
+	// Target is the rendered path relative to the output directory, without the .tmpl extension.
+	// For skipped entries it is the unrendered path.
+	Target string
+	// Skipped is the reason the file is not written, empty if it is written.
+	Skipped string
+}
+
+// IsTemplate reports whether the entry is rendered rather than copied.
+func (e *Entry) IsTemplate() bool {
+	return e.File.IsTemplate()
+}
+
+// Plan determines which files of the template are written where, without writing anything.
+// Files excluded by manifest rules or by a path segment rendered to an empty name are
+// returned with the reason in Entry.Skipped.
+func (t *Template) Plan(paramsMap map[string]interface{}) ([]Entry, error) {
+	files, err := t.Files()
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []Entry
+	for _, f := range files {
+		entry := Entry{Template: t.Name, Priority: t.Manifest.Priority, File: f, Target: f.RelPath}
+		if f.IsTemplate() {
+			entry.Target = strings.TrimSuffix(f.RelPath, ".tmpl")
+		}
+		include, err := included(t.Manifest, f.RelPath, paramsMap)
+		if err != nil {
+			return nil, err
+		}
+		if !include {
+			entry.Skipped = SkipExcluded
+			entries = append(entries, entry)
+			continue
+		}
+
+		// Render template expressions in file and directory names.
+		renderedPath, err := templater.RenderPath(f.RelPath, paramsMap)
+		if err != nil {
+			return nil, err
+		}
+		// A path segment rendered to an empty name excludes the file.
+		if renderedPath == "" {
+			entry.Skipped = SkipEmptySegment
+			entries = append(entries, entry)
+			continue
+		}
+		if f.IsTemplate() {
+			// Remove the .tmpl extension from the target path.
+			renderedPath = strings.TrimSuffix(renderedPath, ".tmpl")
+		}
+		// Symbolic links must not point outside of the generated project.
+		if f.Link != "" && (filepath.IsAbs(f.Link) || !filepath.IsLocal(filepath.Join(filepath.Dir(renderedPath), f.Link))) {
+			return nil, fmt.Errorf("symlink %s -> %s points outside the output directory", filepath.ToSlash(renderedPath), f.Link)
+		}
+		entry.Target = renderedPath
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// sameFile reports whether two entries write the same sources to the same target, as the files of
+// a parent template do when it is extended by several templates.
+func (e Entry) sameFile(other Entry) bool {
+	return e.Target == other.Target && slices.Equal(e.File.Sources, other.File.Sources)
+}
+
+// ConflictError reports output paths produced by more than one template with the same priority.
+type ConflictError struct {
+	// Conflicts maps each conflicting output path to the templates producing it.
+	Conflicts map[string][]string
+}
+
+func (e *ConflictError) Error() string {
+	paths := make([]string, 0, len(e.Conflicts))
+	for p := range e.Conflicts {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	lines := make([]string, len(paths))
+	for i, p := range paths {
+		lines[i] = fmt.Sprintf("  %s (%s)", filepath.ToSlash(p), strings.Join(e.Conflicts[p], ", "))
+	}
+	return "conflicting output paths:\n" + strings.Join(lines, "\n")
+}
+
+// Compose plans the generation of several templates into the same output directory.
+// When templates produce the same output path, the one with the higher manifest priority wins;
+// paths produced by several templates of equal priority are all reported in a ConflictError
+// before anything is written. Skipped entries are kept unless another template writes their path.
+// Files of a parent template shared by several templates, which have the same sources, are planned once.
+func Compose(templates []*Template, paramsMap map[string]interface{}) ([]Entry, error) {
+	byTarget := make(map[string][]Entry)
+	var order []string
+	var skipped []Entry
+	for _, t := range templates {
+		entries, err := t.Plan(paramsMap)
+		if err != nil {
+			return nil, fmt.Errorf("template %s: %w", t.Name, err)
+		}
+		for _, e := range entries {
+			if e.Skipped != "" {
+				if !slices.ContainsFunc(skipped, e.sameFile) {
+					skipped = append(skipped, e)
+				}
+				continue
+			}
+			if slices.ContainsFunc(byTarget[e.Target], e.sameFile) {
+				continue
+			}
+			if _, exists := byTarget[e.Target]; !exists {
+				order = append(order, e.Target)
+			}
+			byTarget[e.Target] = append(byTarget[e.Target], e)
+		}
+	}
+
+	conflicts := make(map[string][]string)
+	var composed []Entry
+	for _, target := range order {
+		candidates := byTarget[target]
+		winner := candidates[0]
+		tied := []string{winner.Template}
+		for _, c := range candidates[1:] {
+			switch {
+			case c.Priority > winner.Priority:
+				winner, tied = c, []string{c.Template}
+			case c.Priority == winner.Priority:
+				tied = append(tied, c.Template)
+			}
+		}
+		if len(tied) > 1 {
+			conflicts[target] = tied
+			continue
+		}
+		composed = append(composed, winner)
+	}
+	if len(conflicts) > 0 {
+		return nil, &ConflictError{Conflicts: conflicts}
+	}
+	for _, e := range skipped {
+		if _, written := byTarget[e.Target]; !written {
+			composed = append(composed, e)
+		}
+	}
+	return composed, nil
+}
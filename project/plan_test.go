@@ -0,0 +1,130 @@
+package project
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// TestPlan verifies the rendered targets of a template.
+func TestPlan(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"app/projgen.yaml":        "rules:\n  - exclude: \"*.bak\"\n",
+		"app/{{ .name }}.go.tmpl": "package {{ .name }}",
+		"app/README.md":           "readme",
+		"app/old.bak":             "backup",
+	})
+	tmpl, err := LoadTemplate(filepath.Join(root, "app"))
+	if err != nil {
+		t.Fatalf("LoadTemplate returned error: %v", err)
+	}
+
+	entries, err := tmpl.Plan(map[string]interface{}{"name": "demo"})
+	if err != nil {
+		t.Fatalf("Plan returned error: %v", err)
+	}
+	var targets, skipped []string
+	for _, e := range entries {
+		if e.Skipped != "" {
+			skipped = append(skipped, e.Target)
+			continue
+		}
+		targets = append(targets, e.Target)
+	}
+	if !reflect.DeepEqual(targets, []string{"README.md", "demo.go"}) {
+		t.Errorf("Plan targets = %v", targets)
+	}
+	if !reflect.DeepEqual(skipped, []string{"old.bak"}) {
+		t.Errorf("Plan skipped = %v", skipped)
+	}
+	if entries[0].IsTemplate() || !entries[2].IsTemplate() {
+		t.Errorf("unexpected template detection: %+v", entries)
+	}
+}
+
+// TestCompose verifies merging of several templates and conflict detection.
+func TestCompose(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"service/main.go":         "package main",
+		"service/README.md":       "service",
+		"service/.gitignore":      "bin/",
+		"docker/Dockerfile":       "FROM scratch",
+		"docker/README.md":        "docker",
+		"ci/projgen.yaml":         "priority: 10\n",
+		"ci/.gitignore":           "bin/\nci/",
+		"ci/.github/workflow.yml": "on: push",
+	})
+	load := func(name string) *Template {
+		tmpl, err := LoadTemplate(filepath.Join(root, name))
+		if err != nil {
+			t.Fatalf("LoadTemplate(%s) returned error: %v", name, err)
+		}
+		return tmpl
+	}
+	params := map[string]interface{}{}
+
+	entries, err := Compose([]*Template{load("service"), load("ci")}, params)
+	if err != nil {
+		t.Fatalf("Compose returned error: %v", err)
+	}
+	sources := make(map[string]string)
+	for _, e := range entries {
+		sources[filepath.ToSlash(e.Target)] = e.Template
+	}
+	expected := map[string]string{
+		".gitignore":           "ci",
+		"README.md":            "service",
+		"main.go":              "service",
+		".github/workflow.yml": "ci",
+	}
+	if !reflect.DeepEqual(sources, expected) {
+		t.Errorf("Compose() = %v; want %v", sources, expected)
+	}
+
+	_, err = Compose([]*Template{load("service"), load("docker"), load("ci")}, params)
+	var conflictErr *ConflictError
+	if !errors.As(err, &conflictErr) {
+		t.Fatalf("expected ConflictError, got %v", err)
+	}
+	if !reflect.DeepEqual(conflictErr.Conflicts, map[string][]string{"README.md": {"service", "docker"}}) {
+		t.Errorf("unexpected conflicts: %v", conflictErr.Conflicts)
+	}
+}
+
+// TestComposeSharedParent verifies that the files of a parent extended by several templates are
+// planned once rather than reported as conflicts.
+func TestComposeSharedParent(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"base/common.txt": "common",
+		"a/projgen.yaml":  "extends: base\n",
+		"a/a.txt":         "a",
+		"b/projgen.yaml":  "extends: base\n",
+		"b/b.txt":         "b",
+	})
+	var templates []*Template
+	for _, name := range []string{"a", "b"} {
+		tmpl, err := LoadTemplate(filepath.Join(root, name))
+		if err != nil {
+			t.Fatalf("LoadTemplate(%s) returned error: %v", name, err)
+		}
+		templates = append(templates, tmpl)
+	}
+
+	entries, err := Compose(templates, map[string]interface{}{})
+	if err != nil {
+		t.Fatalf("Compose returned error: %v", err)
+	}
+	var targets []string
+	for _, e := range entries {
+		targets = append(targets, filepath.ToSlash(e.Target))
+	}
+	sort.Strings(targets)
+	if expected := []string{"a.txt", "b.txt", "common.txt"}; !reflect.DeepEqual(targets, expected) {
+		t.Errorf("Compose() targets = %v; want %v", targets, expected)
+	}
+}
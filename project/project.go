@@ -1,62 +1,116 @@
 package project
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
-	"strings"
 
-	"github.com/dirtydriver/projgen/filescheck"
+	"github.com/dirtydriver/projgen/manifest"
 	"github.com/dirtydriver/projgen/templater"
 )
 
 // Generate creates a new project from a template directory using the provided parameters.
 // It copies all files from the template, rendering any .tmpl files with the given parameters.
+// Template expressions in file and directory names are rendered with the same parameters.
+// Files are skipped when a path segment renders to an empty name or a manifest rule excludes them.
+// Templates the directory extends are merged in first (see LoadTemplate).
 func Generate(templateDir, outputDir string, paramsMap map[string]interface{}) error {
+	t, err := LoadTemplate(templateDir)
+	if err != nil {
+		return err
+	}
+	return t.Generate(outputDir, paramsMap)
+}
 
-	fileList, err := filescheck.FilesInDirectories(templateDir)
+// Generate creates a new project from the template using the provided parameters.
+func (t *Template) Generate(outputDir string, paramsMap map[string]interface{}) error {
+	entries, err := t.Plan(paramsMap)
 	if err != nil {
 		return err
 	}
+	return Write(entries, &DiskWriter{Dir: outputDir}, paramsMap)
+}
 
-	for _, file := range fileList {
-		// Compute the relative path from the template directory.
-		relPath, err := filepath.Rel(templateDir, file)
+// Write renders the planned entries and hands them to the writer.
+// Empty directories and symbolic links are passed on as they are.
+func Write(entries []Entry, w Writer, paramsMap map[string]interface{}) error {
+	for _, e := range entries {
+		var err error
+		switch {
+		case e.Skipped != "":
+			err = w.Skip(e)
+		case e.File.Dir:
+			err = w.Mkdir(e)
+		case e.File.Link != "":
+			err = w.Symlink(e)
+		}
 		if err != nil {
-			return fmt.Errorf("failed to determine relative path for %s: %w", file, err)
+			return err
+		}
+		if e.Skipped != "" || e.File.Dir || e.File.Link != "" {
+			continue
 		}
-		targetPath := filepath.Join(outputDir, relPath)
-
-		if templater.IsTemplate(file) {
-			// Render the template with the provided parameters.
-			rendered, err := templater.RenderTemplate(file, paramsMap)
-			if err != nil {
-				return err
-			}
 
-			// Remove the .tmpl extension from the target path.
-			targetPath = strings.TrimSuffix(targetPath, ".tmpl")
+		content, err := Render(e, paramsMap)
+		if err != nil {
+			return err
+		}
+		if err := w.WriteFile(e, content); err != nil {
+			return err
+		}
+	}
+	return nil
+}
 
-			// Ensure the target directory exists.
-			if err := os.MkdirAll(filepath.Dir(targetPath), 0755); err != nil {
-				return fmt.Errorf("failed to create directory for %s: %w", targetPath, err)
-			}
+// Render returns the content of an entry: the rendered template or the content of a plain file.
+func Render(e Entry, paramsMap map[string]interface{}) ([]byte, error) {
+	if e.File.Content != nil {
+		return e.File.Content, nil
+	}
+	if !e.IsTemplate() {
+		return os.ReadFile(e.File.Source())
+	}
+	// Render the template, together with the parent templates whose blocks it overrides.
+	rendered, err := templater.RenderLayered(e.File.Sources, paramsMap)
+	if err != nil {
+		return nil, err
+	}
+	return rendered.Bytes(), nil
+}
 
-			// Write the rendered content to the target path.
-			if err := templater.WriteTemplate(targetPath, &rendered); err != nil {
-				return err
-			}
-		} else {
-			// For non-template files, ensure the directory exists.
-			if err := os.MkdirAll(filepath.Dir(targetPath), 0755); err != nil {
-				return fmt.Errorf("failed to create directory for %s: %w", targetPath, err)
-			}
+// TrialRender renders every file of the entries in memory, without writing anything, and returns
+// the errors of all files together.
+func TrialRender(entries []Entry, paramsMap map[string]interface{}) error {
+	var errs []error
+	for _, e := range entries {
+		if e.Skipped != "" || e.File.Dir || e.File.Link != "" {
+			continue
+		}
+		if _, err := Render(e, paramsMap); err != nil {
+			errs = append(errs, fmt.Errorf("rendering %s: %w", filepath.ToSlash(e.Target), err))
+		}
+	}
+	return errors.Join(errs...)
+}
 
-			// Copy the file.
-			if err := filescheck.CopyFile(file, filepath.Dir(targetPath)); err != nil {
-				return err
+// included evaluates the manifest rules matching a template relative path and reports whether the file is emitted.
+func included(m *manifest.Manifest, relPath string, paramsMap map[string]interface{}) (bool, error) {
+	slashPath := filepath.ToSlash(relPath)
+	for _, rule := range m.Rules {
+		if !rule.Matches(slashPath) {
+			continue
+		}
+		condition := true
+		if rule.When != "" {
+			var err error
+			if condition, err = templater.EvalCondition(rule.When, paramsMap); err != nil {
+				return false, fmt.Errorf("rule for %s: %w", slashPath, err)
 			}
 		}
+		if rule.Include != "" && !condition || rule.Exclude != "" && condition {
+			return false, nil
+		}
 	}
-	return nil
+	return true, nil
 }
@@ -75,3 +75,227 @@ func TestGenerate(t *testing.T) {
 		t.Errorf("static file content mismatch: expected %q, got %q", staticContent, string(staticData))
 	}
 }
+
+// TestGenerateTemplatedPaths verifies that file and directory names containing template expressions are rendered.
+func TestGenerateTemplatedPaths(t *testing.T) {
+	templateDir := t.TempDir()
+	outputDir := t.TempDir()
+
+	srcDir := filepath.Join(templateDir, "src", "{{ .package | packagePath }}")
+	if err := os.MkdirAll(srcDir, 0755); err != nil {
+		t.Fatalf("failed to create template directory: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(srcDir, "Main.java.tmpl"), []byte("package {{ .package }};"), 0644); err != nil {
+		t.Fatalf("failed to write template file: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(srcDir, "{{ .name }}.txt"), []byte("static"), 0644); err != nil {
+		t.Fatalf("failed to write static file: %v", err)
+	}
+
+	params := map[string]interface{}{
+		"package": "com.acme.app",
+		"name":    "notes",
+	}
+	if err := Generate(templateDir, outputDir, params); err != nil {
+		t.Fatalf("Generate returned error: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(outputDir, "src", "com", "acme", "app", "Main.java"))
+	if err != nil {
+		t.Fatalf("failed to read rendered file: %v", err)
+	}
+	if string(data) != "package com.acme.app;" {
+		t.Errorf("rendered content mismatch: got %q", string(data))
+	}
+	if _, err := os.Stat(filepath.Join(outputDir, "src", "com", "acme", "app", "notes.txt")); err != nil {
+		t.Errorf("expected copied file with rendered name: %v", err)
+	}
+}
+
+// TestGenerateConditionalFiles verifies that empty path segments and manifest rules skip files.
+func TestGenerateConditionalFiles(t *testing.T) {
+	templateDir := t.TempDir()
+
+	files := map[string]string{
+		"projgen.yaml":                           "rules:\n  - include: Dockerfile\n    when: .docker\n  - exclude: \"db/**\"\n    when: not .database\n",
+		"Dockerfile":                             "FROM scratch",
+		"db/schema.sql":                          "CREATE TABLE t ();",
+		"{{ if .ci }}.github{{ end }}/build.yml": "on: push",
+		"README.md":                              "readme",
+	}
+	for name, content := range files {
+		path := filepath.Join(templateDir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+
+	tests := []struct {
+		name    string
+		params  map[string]interface{}
+		present []string
+		absent  []string
+	}{
+		{
+			name:    "all disabled",
+			params:  map[string]interface{}{"docker": false, "database": false, "ci": false},
+			present: []string{"README.md"},
+			absent:  []string{"Dockerfile", "db/schema.sql", ".github/build.yml", "projgen.yaml"},
+		},
+		{
+			name:    "all enabled",
+			params:  map[string]interface{}{"docker": true, "database": true, "ci": true},
+			present: []string{"README.md", "Dockerfile", "db/schema.sql", ".github/build.yml"},
+			absent:  []string{"projgen.yaml"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outputDir := t.TempDir()
+			if err := Generate(templateDir, outputDir, tt.params); err != nil {
+				t.Fatalf("Generate returned error: %v", err)
+			}
+			for _, name := range tt.present {
+				if _, err := os.Stat(filepath.Join(outputDir, filepath.FromSlash(name))); err != nil {
+					t.Errorf("expected %s to be generated: %v", name, err)
+				}
+			}
+			for _, name := range tt.absent {
+				if _, err := os.Stat(filepath.Join(outputDir, filepath.FromSlash(name))); err == nil {
+					t.Errorf("expected %s to be skipped", name)
+				}
+			}
+		})
+	}
+}
+
+// TestGenerateModesDirsAndLinks verifies that file modes, empty directories and symlinks are reproduced.
+func TestGenerateModesDirsAndLinks(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"app/projgen.yaml":    "modes:\n  - path: \"scripts/*\"\n    mode: \"0700\"\n",
+		"app/mvnw":            "#!/bin/sh\n",
+		"app/run.sh.tmpl":     "#!/bin/sh\necho {{ .name }}\n",
+		"app/scripts/deploy":  "#!/bin/sh\n",
+		"app/docs/guide.md":   "guide",
+		"escape/projgen.yaml": "",
+	})
+	templateDir := filepath.Join(root, "app")
+	for _, name := range []string{"mvnw", "run.sh.tmpl"} {
+		if err := os.Chmod(filepath.Join(templateDir, name), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.MkdirAll(filepath.Join(templateDir, "src", "test", "resources"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("docs/guide.md", filepath.Join(templateDir, "GUIDE.md")); err != nil {
+		t.Fatal(err)
+	}
+
+	outputDir := filepath.Join(root, "out")
+	if err := Generate(templateDir, outputDir, map[string]interface{}{"name": "demo"}); err != nil {
+		t.Fatalf("Generate returned error: %v", err)
+	}
+
+	modes := map[string]os.FileMode{
+		"mvnw":           0755,
+		"run.sh":         0755,
+		"scripts/deploy": 0700,
+		"docs/guide.md":  0644,
+	}
+	for name, want := range modes {
+		info, err := os.Stat(filepath.Join(outputDir, name))
+		if err != nil {
+			t.Fatalf("stat %s: %v", name, err)
+		}
+		if info.Mode().Perm() != want {
+			t.Errorf("%s has mode %v; want %v", name, info.Mode().Perm(), want)
+		}
+	}
+	if info, err := os.Stat(filepath.Join(outputDir, "src", "test", "resources")); err != nil || !info.IsDir() {
+		t.Errorf("empty directory src/test/resources was not created: %v", err)
+	}
+	if link, err := os.Readlink(filepath.Join(outputDir, "GUIDE.md")); err != nil || link != "docs/guide.md" {
+		t.Errorf("GUIDE.md = %q, %v; want a symlink to docs/guide.md", link, err)
+	}
+
+	// Links pointing outside the output directory are rejected.
+	escapeDir := filepath.Join(root, "escape")
+	if err := os.Symlink("../../etc/passwd", filepath.Join(escapeDir, "passwd")); err != nil {
+		t.Fatal(err)
+	}
+	err := Generate(escapeDir, filepath.Join(root, "out2"), map[string]interface{}{})
+	if err == nil || !strings.Contains(err.Error(), "points outside the output directory") {
+		t.Errorf("expected an error for an escaping symlink, got %v", err)
+	}
+}
+
+// TestGenerateLinkChain verifies that links which each stay inside cannot be combined to write
+// outside the output directory.
+func TestGenerateLinkChain(t *testing.T) {
+	root := t.TempDir()
+	templateDir := filepath.Join(root, "chain")
+	writeFiles(t, root, map[string]string{
+		"chain/{{ \"esc\" }}/evil.txt": "evil",
+	})
+	if err := os.MkdirAll(filepath.Join(templateDir, "x"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("..", filepath.Join(templateDir, "x", "up")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("x/up/..", filepath.Join(templateDir, "esc")); err != nil {
+		t.Fatal(err)
+	}
+	outputDir := filepath.Join(root, "work", "out")
+
+	err := Generate(templateDir, outputDir, map[string]interface{}{})
+	if err == nil || !strings.Contains(err.Error(), "leaves the output directory") {
+		t.Errorf("expected an error for a chain of links leaving the output directory, got %v", err)
+	}
+	if _, err := os.Lstat(filepath.Join(root, "work", "evil.txt")); !os.IsNotExist(err) {
+		t.Errorf("evil.txt was written outside the output directory")
+	}
+}
+
+// TestTrialRender verifies that the errors of all files are reported and nothing is written.
+func TestTrialRender(t *testing.T) {
+	templateDir := t.TempDir()
+	files := map[string]string{
+		"ok.txt.tmpl":   "{{ .name }}",
+		"bad1.txt.tmpl": "{{ .count | upper }}",
+		"bad2.txt.tmpl": "{{ index .name 5 }}",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(templateDir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write template file: %v", err)
+		}
+	}
+	tmpl, err := LoadTemplate(templateDir)
+	if err != nil {
+		t.Fatalf("LoadTemplate returned error: %v", err)
+	}
+	params := map[string]interface{}{"name": "ab", "count": 3}
+	entries, err := Compose([]*Template{tmpl}, params)
+	if err != nil {
+		t.Fatalf("Compose returned error: %v", err)
+	}
+
+	err = TrialRender(entries, params)
+	if err == nil {
+		t.Fatal("expected an error, got nil")
+	}
+	for _, target := range []string{"rendering bad1.txt:", "rendering bad2.txt:"} {
+		if !strings.Contains(err.Error(), target) {
+			t.Errorf("error %q does not mention %q", err, target)
+		}
+	}
+	if strings.Contains(err.Error(), "ok.txt") {
+		t.Errorf("error %q mentions a valid file", err)
+	}
+}
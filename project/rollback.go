@@ -0,0 +1,131 @@
+package project
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// Snapshot records which paths existed in an output directory before generation, and the original
+// content of the files replaced since, so that a failed generation can be rolled back.
+type Snapshot struct {
+	dir     string
+	existed bool
+	paths   map[string]bool
+	saved   map[string]savedFile
+}
+
+// savedFile is the original content and mode of a replaced file, or the target of a replaced link.
+type savedFile struct {
+	content []byte
+	mode    os.FileMode
+	link    string
+	isLink  bool
+}
+
+// TakeSnapshot records the current contents of the output directory.
+func TakeSnapshot(dir string) (*Snapshot, error) {
+	s := &Snapshot{dir: dir, paths: make(map[string]bool), saved: make(map[string]savedFile)}
+	if _, err := os.Stat(dir); errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	} else if err != nil {
+		return nil, err
+	}
+	s.existed = true
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		s.paths[path] = true
+		return nil
+	})
+	return s, err
+}
+
+// Save records the content and mode of an existing file, or the target of an existing link, before
+// it is replaced, so that Rollback can restore it. Paths created since the snapshot, paths saved
+// before and directories are not recorded. Save does nothing on a nil Snapshot.
+func (s *Snapshot) Save(path string) error {
+	if s == nil || !s.paths[path] {
+		return nil
+	}
+	if _, ok := s.saved[path]; ok {
+		return nil
+	}
+	info, err := os.Lstat(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	switch {
+	case info.Mode()&os.ModeSymlink != 0:
+		link, err := os.Readlink(path)
+		if err != nil {
+			return err
+		}
+		s.saved[path] = savedFile{link: link, isLink: true}
+	case info.Mode().IsRegular():
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		s.saved[path] = savedFile{content: content, mode: info.Mode().Perm()}
+	}
+	return nil
+}
+
+// Rollback removes every file and directory created since the snapshot was taken and restores
+// the files recorded with Save. If the output directory did not exist before, it is removed entirely.
+// Other files that existed before are left as they are.
+func (s *Snapshot) Rollback() error {
+	if !s.existed {
+		return os.RemoveAll(s.dir)
+	}
+	var created []string
+	err := filepath.Walk(s.dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if s.paths[path] {
+			return nil
+		}
+		created = append(created, path)
+		if info.IsDir() {
+			return filepath.SkipDir
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for _, path := range created {
+		if err := os.RemoveAll(path); err != nil {
+			return err
+		}
+	}
+	var errs []error
+	for path, saved := range s.saved {
+		errs = append(errs, saved.restore(path))
+	}
+	return errors.Join(errs...)
+}
+
+// restore writes the saved file or link back to path, replacing what is there now.
+func (f savedFile) restore(path string) error {
+	if err := os.RemoveAll(path); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	if f.isLink {
+		return os.Symlink(f.link, path)
+	}
+	if err := os.WriteFile(path, f.content, f.mode); err != nil {
+		return err
+	}
+	return os.Chmod(path, f.mode)
+}
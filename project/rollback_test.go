@@ -0,0 +1,109 @@
+package project
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestRollback verifies that only paths created after the snapshot are removed.
+func TestRollback(t *testing.T) {
+	outputDir := t.TempDir()
+	existing := filepath.Join(outputDir, "existing.txt")
+	if err := os.WriteFile(existing, []byte("keep"), 0644); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+
+	snapshot, err := TakeSnapshot(outputDir)
+	if err != nil {
+		t.Fatalf("TakeSnapshot returned error: %v", err)
+	}
+
+	created := filepath.Join(outputDir, "src", "main.go")
+	if err := os.MkdirAll(filepath.Dir(created), 0755); err != nil {
+		t.Fatalf("failed to create directory: %v", err)
+	}
+	if err := os.WriteFile(created, []byte("package main"), 0644); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+
+	if err := snapshot.Rollback(); err != nil {
+		t.Fatalf("Rollback returned error: %v", err)
+	}
+	if _, err := os.Stat(existing); err != nil {
+		t.Errorf("expected existing file to be kept: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(outputDir, "src")); !os.IsNotExist(err) {
+		t.Errorf("expected created directory to be removed, got %v", err)
+	}
+}
+
+// TestRollbackNewDirectory verifies that an output directory created by the generation is removed.
+func TestRollbackNewDirectory(t *testing.T) {
+	outputDir := filepath.Join(t.TempDir(), "new")
+	snapshot, err := TakeSnapshot(outputDir)
+	if err != nil {
+		t.Fatalf("TakeSnapshot returned error: %v", err)
+	}
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		t.Fatalf("failed to create directory: %v", err)
+	}
+	if err := snapshot.Rollback(); err != nil {
+		t.Fatalf("Rollback returned error: %v", err)
+	}
+	if _, err := os.Stat(outputDir); !os.IsNotExist(err) {
+		t.Errorf("expected output directory to be removed, got %v", err)
+	}
+}
+
+// TestRollbackRestoresReplacedFiles verifies that a render failing after existing files were
+// overwritten or backed up restores their original content, mode and backups.
+func TestRollbackRestoresReplacedFiles(t *testing.T) {
+	for _, policy := range []string{OnConflictOverwrite, OnConflictBackup} {
+		root := t.TempDir()
+		writeFiles(t, root, map[string]string{
+			"app/a.txt":      "NEW",
+			"app/b.txt":      "NEW",
+			"app/z.txt.tmpl": `{{ fail "boom" }}`,
+			"out/a.txt":      "ORIGINAL",
+			"out/b.txt.orig": "OLD BACKUP",
+		})
+		outputDir := filepath.Join(root, "out")
+		if err := os.WriteFile(filepath.Join(outputDir, "b.txt"), []byte("ORIGINAL B"), 0755); err != nil {
+			t.Fatalf("failed to write file: %v", err)
+		}
+		tmpl, err := LoadTemplate(filepath.Join(root, "app"))
+		if err != nil {
+			t.Fatalf("LoadTemplate returned error: %v", err)
+		}
+		entries, err := tmpl.Plan(nil)
+		if err != nil {
+			t.Fatalf("Plan returned error: %v", err)
+		}
+
+		snapshot, err := TakeSnapshot(outputDir)
+		if err != nil {
+			t.Fatalf("TakeSnapshot returned error: %v", err)
+		}
+		w := &DiskWriter{Dir: outputDir, OnConflict: policy, Snapshot: snapshot}
+		if err := Write(entries, w, nil); err == nil {
+			t.Fatalf("%s: Write succeeded; want the render error", policy)
+		}
+		if err := snapshot.Rollback(); err != nil {
+			t.Fatalf("%s: Rollback returned error: %v", policy, err)
+		}
+
+		for name, expected := range map[string]string{"a.txt": "ORIGINAL", "b.txt": "ORIGINAL B", "b.txt.orig": "OLD BACKUP"} {
+			data, err := os.ReadFile(filepath.Join(outputDir, name))
+			if err != nil || string(data) != expected {
+				t.Errorf("%s: %s = %q, %v; want %q", policy, name, data, err, expected)
+			}
+		}
+		if info, err := os.Stat(filepath.Join(outputDir, "b.txt")); err != nil || info.Mode().Perm() != 0755 {
+			t.Errorf("%s: b.txt mode not restored: %v, %v", policy, info, err)
+		}
+		if _, err := os.Stat(filepath.Join(outputDir, "a.txt.orig")); !os.IsNotExist(err) {
+			t.Errorf("%s: a.txt.orig left behind: %v", policy, err)
+		}
+	}
+}
@@ -0,0 +1,178 @@
+package project
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/dirtydriver/projgen/filescheck"
+	"github.com/dirtydriver/projgen/manifest"
+	"github.com/dirtydriver/projgen/templater"
+)
+
+// Template is a template type directory resolved together with the templates it extends.
+type Template struct {
+	// Name is the template type, i.e. the name of its directory.
+	Name string
+	// Dir is the template type directory.
+	Dir string
+	// Layers are the directories making up the template, from the most basic parent to Dir itself.
+	Layers []string
+	// Manifest is the manifest of the template merged with the manifests of its parents.
+	Manifest *manifest.Manifest
+}
+
+// File is a file of a layered template.
+type File struct {
+	// RelPath is the path relative to the template directories, e.g. "src/main.go.tmpl".
+	RelPath string
+	// Sources are the layer files providing this path, from the base layer to the most specific one.
+	// Only the last source is used unless it is a template overriding blocks of the ones before it.
+	Sources []string
+	// Mode holds the permissions of the source or the mode the manifest sets for the path.
+	Mode os.FileMode
+	// Link is the target of a symbolic link, which is recreated rather than copied.
+	Link string
+	// Dir marks an empty directory, which is recreated in the output.
+	Dir bool
+	// Content is the content of a file projgen generates itself, such as the answers file, which
+	// has no sources.
+	Content []byte
+}
+
+// IsTemplate reports whether the file is rendered rather than copied.
+func (f *File) IsTemplate() bool {
+	return f.Content == nil && f.Link == "" && !f.Dir && templater.IsTemplate(f.Source())
+}
+
+// Source returns the most specific file providing this path.
+func (f *File) Source() string {
+	return f.Sources[len(f.Sources)-1]
+}
+
+// LoadTemplate loads the template type in dir and the templates it extends.
+// Parents named in the manifest's extends list are sibling directories of dir, so
+// "extends: base" in templates/maven/projgen.yaml refers to templates/base.
+// Parents may extend other templates in turn; cycles are reported as errors.
+func LoadTemplate(dir string) (*Template, error) {
+	if !filescheck.IsDirectoryExists(dir) {
+		return nil, fmt.Errorf("template directory %s does not exist", dir)
+	}
+	t := &Template{Name: filepath.Base(dir), Dir: dir}
+	m, err := t.resolve(dir, nil, make(map[string]bool))
+	if err != nil {
+		return nil, err
+	}
+	t.Manifest = m
+	return t, nil
+}
+
+// resolve appends the layers of dir to the template, parents first, and returns the merged manifest.
+func (t *Template) resolve(dir string, stack []string, seen map[string]bool) (*manifest.Manifest, error) {
+	for _, s := range stack {
+		if s == dir {
+			return nil, fmt.Errorf("template inheritance cycle: %s -> %s", strings.Join(stack, " -> "), dir)
+		}
+	}
+	m, err := manifest.Load(dir)
+	if err != nil {
+		return nil, err
+	}
+	merged := &manifest.Manifest{}
+	for _, parent := range m.Extends {
+		parentDir := filepath.Join(filepath.Dir(dir), parent)
+		if !filescheck.IsDirectoryExists(parentDir) {
+			return nil, fmt.Errorf("template %s extends unknown template %s", filepath.Base(dir), parent)
+		}
+		parentManifest, err := t.resolve(parentDir, append(stack, dir), seen)
+		if err != nil {
+			return nil, err
+		}
+		merged = manifest.Merge(merged, parentManifest)
+	}
+	// A template reached through several parents contributes its layer only once.
+	if !seen[dir] {
+		seen[dir] = true
+		t.Layers = append(t.Layers, dir)
+	}
+	return manifest.Merge(merged, m), nil
+}
+
+// Files returns the files of all layers merged by path, with files of more specific layers
+// overriding files of their parents. A template file overrides a plain file of the same name
+// and vice versa, e.g. pom.xml.tmpl in a child replaces pom.xml of its parent.
+// Symbolic links and empty directories are included; modes set in the manifest override the
+// modes of the sources. Paths ignored by a layer's .projgenignore and the default ignore patterns,
+// which cover the manifest and the hooks, are not part of the result.
+func (t *Template) Files() ([]File, error) {
+	byTarget := make(map[string]*File)
+	for _, layer := range t.Layers {
+		fileList, err := filescheck.FilesInDirectories(layer)
+		if err != nil {
+			return nil, err
+		}
+		dirList, err := filescheck.EmptyDirectories(layer)
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range append(fileList, dirList...) {
+			relPath, err := filepath.Rel(layer, file)
+			if err != nil {
+				return nil, fmt.Errorf("failed to determine relative path for %s: %w", file, err)
+			}
+			info, err := os.Lstat(file)
+			if err != nil {
+				return nil, err
+			}
+			next := File{RelPath: relPath, Mode: info.Mode().Perm(), Dir: info.IsDir(), Sources: []string{file}}
+			if info.Mode()&os.ModeSymlink != 0 {
+				if next.Link, err = os.Readlink(file); err != nil {
+					return nil, err
+				}
+			}
+
+			target := relPath
+			if next.IsTemplate() {
+				target = strings.TrimSuffix(relPath, ".tmpl")
+			}
+			// Only consecutive templates can extend each other; anything else starts over.
+			if f, exists := byTarget[target]; exists && next.IsTemplate() && f.IsTemplate() {
+				next.Sources = append(f.Sources, file)
+			}
+			if mode, ok := t.Manifest.ModeFor(filepath.ToSlash(relPath)); ok {
+				next.Mode = mode
+			}
+			byTarget[target] = &next
+		}
+	}
+
+	files := make([]File, 0, len(byTarget))
+	for _, f := range byTarget {
+		files = append(files, *f)
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].RelPath < files[j].RelPath
+	})
+	return files, nil
+}
+
+// AnalysisFiles returns the files analysed for parameters, in the form of templater.AnalyzeLayered:
+// the sources of templates, including the parent files whose blocks they override, and the plain
+// files, links and empty directories, whose templated names may use parameters as well.
+func (t *Template) AnalysisFiles() ([][]string, error) {
+	files, err := t.Files()
+	if err != nil {
+		return nil, err
+	}
+	var paths [][]string
+	for _, f := range files {
+		if f.IsTemplate() {
+			paths = append(paths, f.Sources)
+			continue
+		}
+		paths = append(paths, []string{f.Source()})
+	}
+	return paths, nil
+}
@@ -0,0 +1,173 @@
+package project
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/dirtydriver/projgen/templater"
+)
+
+// writeFiles creates the given files, keyed by slash separated path, below root.
+func writeFiles(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+}
+
+// TestLoadTemplate verifies resolution of template layers and manifest merging.
+func TestLoadTemplate(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"base/projgen.yaml":    "parameters:\n  - name: license\n    default: MIT\n",
+		"ci/projgen.yaml":      "extends: base\n",
+		"service/projgen.yaml": "extends: [base, ci]\nparameters:\n  - name: license\n    default: Apache-2.0\n",
+	})
+
+	tmpl, err := LoadTemplate(filepath.Join(root, "service"))
+	if err != nil {
+		t.Fatalf("LoadTemplate returned error: %v", err)
+	}
+	expected := []string{filepath.Join(root, "base"), filepath.Join(root, "ci"), filepath.Join(root, "service")}
+	if !reflect.DeepEqual(tmpl.Layers, expected) {
+		t.Errorf("Layers = %v; want %v", tmpl.Layers, expected)
+	}
+	if tmpl.Name != "service" || tmpl.Manifest.Parameter("license").Default != "Apache-2.0" {
+		t.Errorf("unexpected template: %+v", tmpl)
+	}
+}
+
+// TestLoadTemplateErrors verifies that cycles and unknown parents are reported.
+func TestLoadTemplateErrors(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"a/projgen.yaml":       "extends: b\n",
+		"b/projgen.yaml":       "extends: a\n",
+		"orphan/projgen.yaml":  "extends: missing\n",
+		"standalone/README.md": "readme",
+	})
+
+	if _, err := LoadTemplate(filepath.Join(root, "a")); err == nil || !strings.Contains(err.Error(), "cycle") {
+		t.Errorf("expected cycle error, got %v", err)
+	}
+	if _, err := LoadTemplate(filepath.Join(root, "orphan")); err == nil {
+		t.Error("expected error for unknown parent, got nil")
+	}
+	if _, err := LoadTemplate(filepath.Join(root, "nope")); err == nil {
+		t.Error("expected error for missing template directory, got nil")
+	}
+}
+
+// TestGenerateInheritance verifies child-overrides-parent semantics for files and blocks.
+func TestGenerateInheritance(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"base/LICENSE":             "MIT",
+		"base/.editorconfig":       "root = true",
+		"base/README.md.tmpl":      "# {{ .name }}\n{{ block \"usage\" . }}no usage{{ end }}",
+		"base/hooks/post-generate": "#!/bin/sh\n",
+		"base/projgen.yaml":        "name: base\n",
+		"child/projgen.yaml":       "extends: base\n",
+		"child/LICENSE.tmpl":       "{{ .license }}",
+		"child/README.md.tmpl":     "{{ define \"usage\" }}run {{ .name }}{{ end }}",
+		"child/src/main.go":        "package main",
+		"child/.editorconfig":      "root = false",
+	})
+	outputDir := t.TempDir()
+
+	params := map[string]interface{}{"name": "demo", "license": "Apache-2.0"}
+	if err := Generate(filepath.Join(root, "child"), outputDir, params); err != nil {
+		t.Fatalf("Generate returned error: %v", err)
+	}
+
+	expected := map[string]string{
+		"LICENSE":       "Apache-2.0",
+		".editorconfig": "root = false",
+		"README.md":     "# demo\nrun demo",
+		"src/main.go":   "package main",
+	}
+	for name, content := range expected {
+		data, err := os.ReadFile(filepath.Join(outputDir, filepath.FromSlash(name)))
+		if err != nil {
+			t.Errorf("failed to read %s: %v", name, err)
+			continue
+		}
+		if string(data) != content {
+			t.Errorf("%s = %q; want %q", name, string(data), content)
+		}
+	}
+	for _, name := range []string{"projgen.yaml", "hooks"} {
+		if _, err := os.Stat(filepath.Join(outputDir, name)); err == nil {
+			t.Errorf("expected %s not to be generated", name)
+		}
+	}
+}
+
+// TestFilesIgnore verifies that ignored paths are neither generated nor analysed.
+func TestFilesIgnore(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"app/.projgenignore":      "README.md\ntests/\n",
+		"app/README.md":           "how to use this template",
+		"app/tests/render.sh":     "#!/bin/sh",
+		"app/tests/fixture.tmpl":  "{{ .fixture }}",
+		"app/main.go.tmpl":        "package {{ .name }}",
+		"app/hooks/post-generate": "#!/bin/sh",
+	})
+	tmpl, err := LoadTemplate(filepath.Join(root, "app"))
+	if err != nil {
+		t.Fatalf("LoadTemplate returned error: %v", err)
+	}
+
+	files, err := tmpl.Files()
+	if err != nil {
+		t.Fatalf("Files returned error: %v", err)
+	}
+	if len(files) != 1 || files[0].RelPath != "main.go.tmpl" {
+		t.Errorf("Files() = %+v; want only main.go.tmpl", files)
+	}
+	analysed, err := tmpl.AnalysisFiles()
+	if err != nil {
+		t.Fatalf("AnalysisFiles returned error: %v", err)
+	}
+	if len(analysed) != 1 || filepath.Base(analysed[0][0]) != "main.go.tmpl" {
+		t.Errorf("AnalysisFiles() = %v; want only main.go.tmpl", analysed)
+	}
+}
+
+// TestAnalysisFiles verifies that plain files are analysed for the parameters in their names.
+func TestAnalysisFiles(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"app/{{ .name }}/README.md": "# readme",
+		"app/main.go.tmpl":          "package {{ .pkg }}",
+	})
+	tmpl, err := LoadTemplate(filepath.Join(root, "app"))
+	if err != nil {
+		t.Fatalf("LoadTemplate returned error: %v", err)
+	}
+	files, err := tmpl.AnalysisFiles()
+	if err != nil {
+		t.Fatalf("AnalysisFiles returned error: %v", err)
+	}
+	params, err := templater.AnalyzeLayered(files)
+	if err != nil {
+		t.Fatalf("AnalyzeLayered returned error: %v", err)
+	}
+	var names []string
+	for _, p := range params {
+		names = append(names, p.Name)
+	}
+	if !reflect.DeepEqual(names, []string{"name", "pkg"}) {
+		t.Errorf("parameters = %v; want [name pkg]", names)
+	}
+}
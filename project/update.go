@@ -0,0 +1,188 @@
+package project
+
+import (
+	"bytes"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/dirtydriver/projgen/filescheck"
+	"github.com/dirtydriver/projgen/merge"
+)
+
+// Statuses of a file changed by Update.
+const (
+	UpdateAdded    = "added"
+	UpdateUpdated  = "updated"
+	UpdateMerged   = "merged"
+	UpdateRemoved  = "removed"
+	UpdateConflict = "conflict"
+	// UpdateKept means the template changed or removed a file the user modified or deleted,
+	// and the user's version was left alone.
+	UpdateKept = "kept"
+)
+
+// FileUpdate reports what Update did to one file of the project.
+type FileUpdate struct {
+	// Path is relative to the project directory.
+	Path   string
+	Status string
+	// Conflicts is the number of conflicting regions marked in the file.
+	Conflicts int
+	// Rejected is the path of the .rej file holding the new template version of a binary file or
+	// symbolic link that could not be merged.
+	Rejected string
+}
+
+// Update applies the changes between two renderings of a template to a generated project with a
+// three-way merge. oldDir holds the project rendered from the template version it was generated from,
+// newDir the same parameters rendered from the new version. Changes the user made in projectDir are kept;
+// where both changed the same lines, conflict markers are written. Binary files and symbolic links that
+// changed on both sides keep the user's version and get the new one next to them as <file>.rej.
+// Files are written with the permissions of the new rendering, merged files keep those of the project.
+func Update(projectDir, oldDir, newDir string) ([]FileUpdate, error) {
+	paths, err := relativeFiles(oldDir, newDir)
+	if err != nil {
+		return nil, err
+	}
+
+	var updates []FileUpdate
+	for _, rel := range paths {
+		update, err := updateFile(projectDir, oldDir, newDir, rel)
+		if err != nil {
+			return nil, err
+		}
+		if update != nil {
+			updates = append(updates, *update)
+		}
+	}
+	return updates, nil
+}
+
+func updateFile(projectDir, oldDir, newDir, rel string) (*FileUpdate, error) {
+	base, err := readVersion(filepath.Join(oldDir, rel))
+	if err != nil {
+		return nil, err
+	}
+	theirs, err := readVersion(filepath.Join(newDir, rel))
+	if err != nil {
+		return nil, err
+	}
+	target := filepath.Join(projectDir, rel)
+	ours, err := readVersion(target)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case base.exists && theirs.exists && base.equal(theirs):
+		// The template did not change the file.
+		return nil, nil
+	case !theirs.exists:
+		// The template no longer produces the file; remove it unless the user changed it.
+		if !ours.exists {
+			return nil, nil
+		}
+		if !ours.equal(base) {
+			return &FileUpdate{Path: rel, Status: UpdateKept}, nil
+		}
+		return &FileUpdate{Path: rel, Status: UpdateRemoved}, os.Remove(target)
+	case !ours.exists && base.exists:
+		// The user deleted a file the template changed.
+		return &FileUpdate{Path: rel, Status: UpdateKept}, nil
+	case !ours.exists:
+		return &FileUpdate{Path: rel, Status: UpdateAdded}, writeUpdate(projectDir, rel, theirs)
+	case ours.equal(theirs):
+		return nil, nil
+	case base.exists && ours.equal(base):
+		return &FileUpdate{Path: rel, Status: UpdateUpdated}, writeUpdate(projectDir, rel, theirs)
+	case base.link || ours.link || theirs.link || merge.IsBinary(base.data) || merge.IsBinary(ours.data) || merge.IsBinary(theirs.data):
+		rejected := rel + ".rej"
+		return &FileUpdate{Path: rel, Status: UpdateConflict, Conflicts: 1, Rejected: rejected},
+			writeUpdate(projectDir, rejected, theirs)
+	}
+
+	merged, conflicts := merge.Merge(base.data, ours.data, theirs.data, "current", "template")
+	status := UpdateMerged
+	if conflicts > 0 {
+		status = UpdateConflict
+	}
+	return &FileUpdate{Path: rel, Status: status, Conflicts: conflicts},
+		writeUpdate(projectDir, rel, fileVersion{data: merged, mode: ours.mode, exists: true})
+}
+
+// relativeFiles returns the sorted union of the files below the given directories, relative to them.
+func relativeFiles(dirs ...string) ([]string, error) {
+	seen := make(map[string]bool)
+	var paths []string
+	for _, dir := range dirs {
+		files, err := filescheck.FilesInDirectories(dir)
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range files {
+			rel, err := filepath.Rel(dir, file)
+			if err != nil {
+				return nil, err
+			}
+			if !seen[rel] {
+				seen[rel] = true
+				paths = append(paths, rel)
+			}
+		}
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
+
+// fileVersion is a file of the project or of one of its renderings: the content and permissions
+// of a regular file, or the target of a symbolic link.
+type fileVersion struct {
+	data   []byte
+	mode   os.FileMode
+	link   bool
+	exists bool
+}
+
+// equal reports whether two versions of a file are the same.
+func (v fileVersion) equal(other fileVersion) bool {
+	return v.exists == other.exists && v.link == other.link && v.mode == other.mode && bytes.Equal(v.data, other.data)
+}
+
+// readVersion reads a file or symbolic link without following it.
+func readVersion(path string) (fileVersion, error) {
+	info, err := os.Lstat(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return fileVersion{}, nil
+	}
+	if err != nil {
+		return fileVersion{}, err
+	}
+	if info.Mode()&fs.ModeSymlink != 0 {
+		link, err := os.Readlink(path)
+		return fileVersion{data: []byte(link), link: true, exists: true}, err
+	}
+	data, err := os.ReadFile(path)
+	return fileVersion{data: data, mode: info.Mode().Perm(), exists: true}, err
+}
+
+// writeUpdate writes a version of a file to the relative path in the project, the way DiskWriter
+// writes generated files: with the permissions of the version, or as a symbolic link.
+func writeUpdate(projectDir, rel string, v fileVersion) error {
+	targetPath := filepath.Join(projectDir, rel)
+	// Replace a link rather than writing through it.
+	if info, err := os.Lstat(targetPath); err == nil && info.Mode()&fs.ModeSymlink != 0 {
+		if err := os.Remove(targetPath); err != nil {
+			return err
+		}
+	}
+	w := &DiskWriter{Dir: projectDir}
+	e := Entry{File: File{RelPath: rel, Mode: v.mode}, Target: rel}
+	if v.link {
+		e.File.Link = string(v.data)
+		return w.Symlink(e)
+	}
+	return w.WriteFile(e, v.data)
+}
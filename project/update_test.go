@@ -0,0 +1,115 @@
+package project
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestUpdate verifies the three-way merge of a template update into a modified project.
+func TestUpdate(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"old/same.txt":      "same\n",
+		"old/clean.txt":     "v1\n",
+		"old/merged.txt":    "a\nb\nc\nd\n",
+		"old/conflict.txt":  "port: 80\n",
+		"old/removed.txt":   "gone\n",
+		"old/modified.txt":  "old\n",
+		"new/same.txt":      "same\n",
+		"new/clean.txt":     "v2\n",
+		"new/merged.txt":    "a\nb\nc\nD\n",
+		"new/conflict.txt":  "port: 8080\n",
+		"new/added.txt":     "new\n",
+		"new/modified.txt":  "old\nchanged\n",
+		"proj/same.txt":     "same\n",
+		"proj/clean.txt":    "v1\n",
+		"proj/merged.txt":   "A\nb\nc\nd\n",
+		"proj/conflict.txt": "port: 9090\n",
+		"proj/removed.txt":  "gone\n",
+		"proj/own.txt":      "untouched\n",
+	})
+	dir := func(name string) string { return filepath.Join(root, name) }
+
+	updates, err := Update(dir("proj"), dir("old"), dir("new"))
+	if err != nil {
+		t.Fatalf("Update returned error: %v", err)
+	}
+	statuses := make(map[string]string)
+	for _, u := range updates {
+		statuses[filepath.ToSlash(u.Path)] = u.Status
+	}
+	expected := map[string]string{
+		"added.txt":    UpdateAdded,
+		"clean.txt":    UpdateUpdated,
+		"merged.txt":   UpdateMerged,
+		"conflict.txt": UpdateConflict,
+		"removed.txt":  UpdateRemoved,
+		"modified.txt": UpdateKept,
+	}
+	if !reflect.DeepEqual(statuses, expected) {
+		t.Errorf("Update() statuses = %v; want %v", statuses, expected)
+	}
+
+	contents := map[string]string{
+		"added.txt":  "new\n",
+		"clean.txt":  "v2\n",
+		"merged.txt": "A\nb\nc\nD\n",
+		"own.txt":    "untouched\n",
+	}
+	for name, want := range contents {
+		data, err := os.ReadFile(filepath.Join(dir("proj"), name))
+		if err != nil || string(data) != want {
+			t.Errorf("%s = %q, %v; want %q", name, data, err, want)
+		}
+	}
+	data, _ := os.ReadFile(filepath.Join(dir("proj"), "conflict.txt"))
+	if !strings.Contains(string(data), "<<<<<<< current\nport: 9090\n=======\nport: 8080\n>>>>>>> template\n") {
+		t.Errorf("conflict.txt lacks conflict markers: %q", data)
+	}
+	if _, err := os.Stat(filepath.Join(dir("proj"), "removed.txt")); !os.IsNotExist(err) {
+		t.Errorf("removed.txt still exists")
+	}
+	if _, err := os.Stat(filepath.Join(dir("proj"), "modified.txt")); !os.IsNotExist(err) {
+		t.Errorf("modified.txt deleted by the user was recreated")
+	}
+}
+
+// TestUpdateModesAndLinks verifies that updates keep file permissions and symbolic links.
+func TestUpdateModesAndLinks(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"old/run.sh":     "#!/bin/sh\n",
+		"new/run.sh":     "#!/bin/sh\nexit 0\n",
+		"new/install.sh": "#!/bin/sh\n",
+		"proj/run.sh":    "#!/bin/sh\n",
+		"new/README.md":  "readme",
+	})
+	dir := func(name string) string { return filepath.Join(root, name) }
+	for _, path := range []string{"old/run.sh", "new/run.sh", "new/install.sh", "proj/run.sh"} {
+		if err := os.Chmod(filepath.Join(root, path), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Symlink("README.md", filepath.Join(dir("new"), "README")); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Update(dir("proj"), dir("old"), dir("new")); err != nil {
+		t.Fatalf("Update returned error: %v", err)
+	}
+	for _, name := range []string{"run.sh", "install.sh"} {
+		info, err := os.Stat(filepath.Join(dir("proj"), name))
+		if err != nil {
+			t.Fatalf("failed to stat %s: %v", name, err)
+		}
+		if info.Mode().Perm() != 0755 {
+			t.Errorf("%s mode = %v; want 0755", name, info.Mode().Perm())
+		}
+	}
+	if link, err := os.Readlink(filepath.Join(dir("proj"), "README")); err != nil || link != "README.md" {
+		t.Errorf("README = %q, %v; want a link to README.md", link, err)
+	}
+}
@@ -0,0 +1,320 @@
+package project
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/dirtydriver/projgen/filescheck"
+)
+
+// Writer receives the files of a generation, see Write.
+type Writer interface {
+	// WriteFile stores the content of an entry at its target path with the entry's mode.
+	WriteFile(e Entry, content []byte) error
+	// Mkdir creates the empty directory of an entry.
+	Mkdir(e Entry) error
+	// Symlink creates the symbolic link of an entry.
+	Symlink(e Entry) error
+	// Skip is called for entries that are not written.
+	Skip(e Entry) error
+}
+
+// Policies for output files that already exist with different content.
+const (
+	// OnConflictFail refuses to overwrite anything. Use Preflight to report all conflicts up front.
+	OnConflictFail = "fail"
+	// OnConflictSkip keeps the existing file.
+	OnConflictSkip = "skip"
+	// OnConflictOverwrite replaces the existing file.
+	OnConflictOverwrite = "overwrite"
+	// OnConflictBackup renames the existing file to <file>.orig and replaces it.
+	OnConflictBackup = "backup"
+	// OnConflictPrompt asks DiskWriter.Confirm for every file.
+	OnConflictPrompt = "prompt"
+)
+
+// OnConflictPolicies lists the valid conflict policies.
+var OnConflictPolicies = []string{OnConflictFail, OnConflictSkip, OnConflictOverwrite, OnConflictBackup, OnConflictPrompt}
+
+// DiskWriter writes the files into an output directory.
+type DiskWriter struct {
+	Dir string
+	// OnConflict is the policy for existing files with different content; empty means OnConflictOverwrite.
+	OnConflict string
+	// Confirm decides whether an existing file is replaced when OnConflict is OnConflictPrompt.
+	Confirm func(target string, existing, content []byte) (bool, error)
+	// Snapshot, when set, saves every existing file before it is replaced, renamed or has its mode
+	// changed, so that a failed generation can restore it.
+	Snapshot *Snapshot
+}
+
+// WriteFile writes the content to the target path, creating parent directories as needed.
+// An existing file with different content is handled according to the OnConflict policy.
+func (w *DiskWriter) WriteFile(e Entry, content []byte) error {
+	targetPath := filepath.Join(w.Dir, e.Target)
+	if err := w.checkInside(e.Target, targetPath); err != nil {
+		return err
+	}
+	mode := fileMode(e)
+	existing, err := os.ReadFile(targetPath)
+	if err == nil {
+		err = w.Snapshot.Save(targetPath)
+	}
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+	case err != nil:
+		return err
+	case bytes.Equal(existing, content):
+		return os.Chmod(targetPath, mode)
+	default:
+		write, err := w.resolveConflict(e.Target, targetPath, existing, content)
+		if err != nil || !write {
+			return err
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(targetPath), 0755); err != nil {
+		return fmt.Errorf("failed to create directory for %s: %w", targetPath, err)
+	}
+	if err := os.WriteFile(targetPath, content, mode); err != nil {
+		return err
+	}
+	// The mode of an existing file is not changed by writing it, and new files are subject to the umask.
+	return os.Chmod(targetPath, mode)
+}
+
+// Mkdir creates the directory with the entry's mode.
+func (w *DiskWriter) Mkdir(e Entry) error {
+	mode := e.File.Mode
+	if mode == 0 {
+		mode = 0755
+	}
+	targetPath := filepath.Join(w.Dir, e.Target)
+	if err := w.checkInside(e.Target, targetPath); err != nil {
+		return err
+	}
+	return os.MkdirAll(targetPath, mode)
+}
+
+// Symlink creates the symbolic link. An existing file or link pointing elsewhere is handled
+// according to the OnConflict policy. Neither the link nor what it points to may leave the output
+// directory through the links written before.
+func (w *DiskWriter) Symlink(e Entry) error {
+	targetPath := filepath.Join(w.Dir, e.Target)
+	if err := w.checkInside(e.Target, filepath.Dir(targetPath)); err != nil {
+		return err
+	}
+	// The link is resolved from the real parent directory without cleaning it, so that ".." in
+	// the link steps out of the directories links in it point to.
+	parent, err := filescheck.RealPath(filepath.Dir(targetPath))
+	if err != nil {
+		return err
+	}
+	if err := w.checkInside(e.Target, parent+string(filepath.Separator)+filepath.FromSlash(e.File.Link)); err != nil {
+		return err
+	}
+	current, err := os.Readlink(targetPath)
+	if err == nil && current == e.File.Link {
+		return nil
+	}
+	if _, err := os.Lstat(targetPath); err == nil {
+		if err := w.Snapshot.Save(targetPath); err != nil {
+			return err
+		}
+		existing := []byte(current)
+		if current == "" {
+			existing, _ = os.ReadFile(targetPath)
+		}
+		write, err := w.resolveConflict(e.Target, targetPath, existing, []byte(e.File.Link))
+		if err != nil || !write {
+			return err
+		}
+		if err := os.Remove(targetPath); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(targetPath), 0755); err != nil {
+		return fmt.Errorf("failed to create directory for %s: %w", targetPath, err)
+	}
+	return os.Symlink(e.File.Link, targetPath)
+}
+
+// Skip does nothing.
+func (w *DiskWriter) Skip(e Entry) error {
+	return nil
+}
+
+// checkInside rejects a path of the target that leaves the output directory through symbolic
+// links written before, such as "esc/f" after "x/up -> .." and "esc -> x/up/..".
+func (w *DiskWriter) checkInside(target, path string) error {
+	inside, err := filescheck.ResolvesWithin(w.Dir, path)
+	if err != nil {
+		return err
+	}
+	if !inside {
+		return fmt.Errorf("%s leaves the output directory through a symbolic link", filepath.ToSlash(target))
+	}
+	return nil
+}
+
+// resolveConflict applies the conflict policy to an existing file and reports whether it is to be replaced.
+func (w *DiskWriter) resolveConflict(target, targetPath string, existing, content []byte) (bool, error) {
+	switch w.OnConflict {
+	case "", OnConflictOverwrite:
+		return true, nil
+	case OnConflictSkip:
+		return false, nil
+	case OnConflictBackup:
+		// A backup left by an earlier run is replaced as well.
+		if err := w.Snapshot.Save(targetPath + ".orig"); err != nil {
+			return false, err
+		}
+		return true, os.Rename(targetPath, targetPath+".orig")
+	case OnConflictPrompt:
+		if w.Confirm == nil {
+			return false, fmt.Errorf("no way to confirm overwriting %s", target)
+		}
+		return w.Confirm(target, existing, content)
+	case OnConflictFail:
+		return false, &ExistingFilesError{Paths: []string{target}}
+	}
+	return false, fmt.Errorf("unknown conflict policy %q", w.OnConflict)
+}
+
+// fileMode returns the permissions a file entry is written with.
+func fileMode(e Entry) os.FileMode {
+	if e.File.Mode == 0 {
+		return 0644
+	}
+	return e.File.Mode
+}
+
+// Statuses of a planned file.
+const (
+	StatusCreate    = "create"
+	StatusOverwrite = "overwrite"
+	StatusUnchanged = "unchanged"
+	StatusSkipped   = "skipped"
+)
+
+// Action describes what a generation would do with one output file.
+type Action struct {
+	// Target is the path relative to the output directory.
+	Target string
+	// Status is one of the Status constants.
+	Status string
+	// Rendered reports whether the file is rendered from a template rather than copied.
+	Rendered bool
+	// Size is the size of the content in bytes.
+	Size int
+	// Mode holds the permissions the file is written with.
+	Mode os.FileMode
+	// Link is the target of a symbolic link.
+	Link string
+	// Dir marks an empty directory.
+	Dir bool
+	// Reason explains why a file is skipped.
+	Reason string
+}
+
+// PlanWriter records what would be written into an output directory without touching it.
+type PlanWriter struct {
+	Dir     string
+	Actions []Action
+}
+
+// WriteFile compares the content with the existing file and records the resulting action.
+func (w *PlanWriter) WriteFile(e Entry, content []byte) error {
+	existing, err := os.ReadFile(filepath.Join(w.Dir, e.Target))
+	status, err := compareExisting(err, func() bool { return bytes.Equal(existing, content) })
+	if err != nil {
+		return err
+	}
+	w.Actions = append(w.Actions, Action{Target: e.Target, Status: status, Rendered: e.IsTemplate(), Size: len(content), Mode: fileMode(e)})
+	return nil
+}
+
+// Mkdir records the creation of an empty directory.
+func (w *PlanWriter) Mkdir(e Entry) error {
+	status := StatusCreate
+	if filescheck.IsDirectoryExists(filepath.Join(w.Dir, e.Target)) {
+		status = StatusUnchanged
+	}
+	w.Actions = append(w.Actions, Action{Target: e.Target, Status: status, Mode: e.File.Mode, Dir: true})
+	return nil
+}
+
+// Symlink compares the link with an existing one and records the resulting action.
+func (w *PlanWriter) Symlink(e Entry) error {
+	targetPath := filepath.Join(w.Dir, e.Target)
+	_, err := os.Lstat(targetPath)
+	status, err := compareExisting(err, func() bool {
+		current, err := os.Readlink(targetPath)
+		return err == nil && current == e.File.Link
+	})
+	if err != nil {
+		return err
+	}
+	w.Actions = append(w.Actions, Action{Target: e.Target, Status: status, Link: e.File.Link})
+	return nil
+}
+
+// Skip records a skipped entry.
+func (w *PlanWriter) Skip(e Entry) error {
+	w.Actions = append(w.Actions, Action{Target: e.Target, Status: StatusSkipped, Rendered: e.IsTemplate(), Reason: e.Skipped})
+	return nil
+}
+
+// compareExisting derives the status of an output path from the error of reading it and whether
+// the existing content is the same.
+func compareExisting(err error, same func() bool) (string, error) {
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		return StatusCreate, nil
+	case err != nil:
+		return "", err
+	case same():
+		return StatusUnchanged, nil
+	}
+	return StatusOverwrite, nil
+}
+
+// ExistingFilesError reports output files that exist with different content.
+type ExistingFilesError struct {
+	Paths []string
+}
+
+func (e *ExistingFilesError) Error() string {
+	lines := make([]string, len(e.Paths))
+	for i, p := range e.Paths {
+		lines[i] = "  " + filepath.ToSlash(p)
+	}
+	return "existing files would be overwritten:\n" + strings.Join(lines, "\n")
+}
+
+// Preflight renders the entries and returns an ExistingFilesError listing every file in outputDir
+// that exists with different content, before anything is written.
+func Preflight(entries []Entry, outputDir string, paramsMap map[string]interface{}) error {
+	plan := &PlanWriter{Dir: outputDir}
+	if err := Write(entries, plan, paramsMap); err != nil {
+		return err
+	}
+	var paths []string
+	for _, a := range plan.Actions {
+		if a.Status == StatusOverwrite {
+			paths = append(paths, a.Target)
+		}
+	}
+	if len(paths) > 0 {
+		return &ExistingFilesError{Paths: paths}
+	}
+	return nil
+}
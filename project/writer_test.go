@@ -0,0 +1,150 @@
+package project
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// TestPlanWriter verifies that a dry run reports every file without writing anything.
+func TestPlanWriter(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"app/projgen.yaml": "rules:\n  - exclude: \"*.bak\"\n",
+		"app/main.go.tmpl": "package {{ .name }}",
+		"app/README.md":    "readme",
+		"app/LICENSE":      "MIT",
+		"app/old.bak":      "backup",
+		"out/README.md":    "old readme",
+		"out/LICENSE":      "MIT",
+	})
+	tmpl, err := LoadTemplate(filepath.Join(root, "app"))
+	if err != nil {
+		t.Fatalf("LoadTemplate returned error: %v", err)
+	}
+	params := map[string]interface{}{"name": "demo"}
+	entries, err := tmpl.Plan(params)
+	if err != nil {
+		t.Fatalf("Plan returned error: %v", err)
+	}
+
+	outputDir := filepath.Join(root, "out")
+	w := &PlanWriter{Dir: outputDir}
+	if err := Write(entries, w, params); err != nil {
+		t.Fatalf("Write returned error: %v", err)
+	}
+	expected := []Action{
+		{Target: "LICENSE", Status: StatusUnchanged, Size: 3, Mode: 0644},
+		{Target: "README.md", Status: StatusOverwrite, Size: 6, Mode: 0644},
+		{Target: "main.go", Status: StatusCreate, Rendered: true, Size: 12, Mode: 0644},
+		{Target: "old.bak", Status: StatusSkipped, Reason: SkipExcluded},
+	}
+	if !reflect.DeepEqual(w.Actions, expected) {
+		t.Errorf("Actions = %+v; want %+v", w.Actions, expected)
+	}
+
+	if _, err := os.Stat(filepath.Join(outputDir, "main.go")); !os.IsNotExist(err) {
+		t.Errorf("dry run wrote main.go")
+	}
+	data, err := os.ReadFile(filepath.Join(outputDir, "README.md"))
+	if err != nil || string(data) != "old readme" {
+		t.Errorf("dry run modified README.md: %q, %v", data, err)
+	}
+}
+
+// TestDiskWriterConflicts verifies the conflict policies for existing files.
+func TestDiskWriterConflicts(t *testing.T) {
+	tests := []struct {
+		policy   string
+		confirm  bool
+		expected string
+		backup   bool
+		wantErr  bool
+	}{
+		{policy: OnConflictOverwrite, expected: "new"},
+		{policy: OnConflictSkip, expected: "old"},
+		{policy: OnConflictBackup, expected: "new", backup: true},
+		{policy: OnConflictPrompt, confirm: true, expected: "new"},
+		{policy: OnConflictPrompt, confirm: false, expected: "old"},
+		{policy: OnConflictFail, expected: "old", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		root := t.TempDir()
+		writeFiles(t, root, map[string]string{
+			"app/README.md": "new",
+			"app/LICENSE":   "MIT",
+			"out/README.md": "old",
+			"out/LICENSE":   "MIT",
+		})
+		tmpl, err := LoadTemplate(filepath.Join(root, "app"))
+		if err != nil {
+			t.Fatalf("LoadTemplate returned error: %v", err)
+		}
+		entries, err := tmpl.Plan(nil)
+		if err != nil {
+			t.Fatalf("Plan returned error: %v", err)
+		}
+		outputDir := filepath.Join(root, "out")
+
+		var asked []string
+		w := &DiskWriter{Dir: outputDir, OnConflict: tt.policy, Confirm: func(target string, existing, content []byte) (bool, error) {
+			asked = append(asked, target)
+			return tt.confirm, nil
+		}}
+		err = Write(entries, w, nil)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: Write returned error %v", tt.policy, err)
+		}
+		data, _ := os.ReadFile(filepath.Join(outputDir, "README.md"))
+		if string(data) != tt.expected {
+			t.Errorf("%s: README.md = %q; want %q", tt.policy, data, tt.expected)
+		}
+		backup, err := os.ReadFile(filepath.Join(outputDir, "README.md.orig"))
+		if tt.backup && string(backup) != "old" {
+			t.Errorf("%s: README.md.orig = %q, %v; want old", tt.policy, backup, err)
+		}
+		if !tt.backup && err == nil {
+			t.Errorf("%s: unexpected README.md.orig", tt.policy)
+		}
+		if tt.policy == OnConflictPrompt && !reflect.DeepEqual(asked, []string{"README.md"}) {
+			t.Errorf("%s: asked for %v; want only README.md", tt.policy, asked)
+		}
+	}
+}
+
+// TestPreflight verifies that all conflicting files are reported together.
+func TestPreflight(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"app/a.txt": "new",
+		"app/b.txt": "same",
+		"app/c.txt": "new",
+		"app/d.txt": "new",
+		"out/a.txt": "old",
+		"out/b.txt": "same",
+		"out/c.txt": "old",
+	})
+	tmpl, err := LoadTemplate(filepath.Join(root, "app"))
+	if err != nil {
+		t.Fatalf("LoadTemplate returned error: %v", err)
+	}
+	entries, err := tmpl.Plan(nil)
+	if err != nil {
+		t.Fatalf("Plan returned error: %v", err)
+	}
+
+	err = Preflight(entries, filepath.Join(root, "out"), nil)
+	var existing *ExistingFilesError
+	if !errors.As(err, &existing) {
+		t.Fatalf("expected ExistingFilesError, got %v", err)
+	}
+	if !reflect.DeepEqual(existing.Paths, []string{"a.txt", "c.txt"}) {
+		t.Errorf("Paths = %v", existing.Paths)
+	}
+	if err := Preflight(entries, filepath.Join(root, "empty"), nil); err != nil {
+		t.Errorf("Preflight into a new directory returned error: %v", err)
+	}
+}
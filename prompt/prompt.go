@@ -0,0 +1,109 @@
+package prompt
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/dirtydriver/projgen/manifest"
+)
+
+// Prompter asks the user for parameter values on an interactive terminal.
+type Prompter struct {
+	in  *bufio.Reader
+	out io.Writer
+}
+
+// New creates a Prompter reading answers from in and writing questions to out.
+func New(in io.Reader, out io.Writer) *Prompter {
+	return &Prompter{in: bufio.NewReader(in), out: out}
+}
+
+// IsTerminal reports whether the file is attached to a character device such as a terminal.
+func IsTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// Ask prompts for the value of the named parameter until a valid answer is given.
+// When a manifest declaration is available its description, default and allowed values are shown,
+// and the answer is converted to the declared type. An empty answer selects the default, if any.
+func (p *Prompter) Ask(name string, decl *manifest.Parameter) (interface{}, error) {
+	if decl == nil {
+		decl = &manifest.Parameter{Name: name}
+	}
+	for {
+		fmt.Fprint(p.out, question(name, decl))
+
+		line, err := p.in.ReadString('\n')
+		if err != nil && !(errors.Is(err, io.EOF) && line != "") {
+			return nil, fmt.Errorf("reading value for %s: %w", name, err)
+		}
+		answer := strings.TrimSpace(line)
+
+		var value interface{} = answer
+		if answer == "" {
+			if decl.Default == nil {
+				fmt.Fprintf(p.out, "A value for %s is required.\n", name)
+				continue
+			}
+			value = decl.Default
+		}
+
+		coerced, err := decl.Coerce(value)
+		if err == nil {
+			err = decl.Validate(coerced)
+		}
+		if err != nil {
+			fmt.Fprintf(p.out, "Invalid value: %v\n", err)
+			continue
+		}
+		return coerced, nil
+	}
+}
+
+// Confirm asks a yes/no question until it is answered. An empty answer means no.
+func (p *Prompter) Confirm(q string) (bool, error) {
+	for {
+		fmt.Fprintf(p.out, "%s [y/N]: ", q)
+
+		line, err := p.in.ReadString('\n')
+		if err != nil && !(errors.Is(err, io.EOF) && line != "") {
+			return false, fmt.Errorf("reading answer: %w", err)
+		}
+		switch strings.ToLower(strings.TrimSpace(line)) {
+		case "y", "yes":
+			return true, nil
+		case "", "n", "no":
+			return false, nil
+		}
+		fmt.Fprintln(p.out, "Please answer y or n.")
+	}
+}
+
+// question formats the prompt line for a parameter.
+func question(name string, decl *manifest.Parameter) string {
+	var b strings.Builder
+	b.WriteString(name)
+	if decl.Description != "" {
+		fmt.Fprintf(&b, " - %s", decl.Description)
+	}
+	if len(decl.Enum) > 0 {
+		choices := make([]string, len(decl.Enum))
+		for i, e := range decl.Enum {
+			choices[i] = fmt.Sprint(e)
+		}
+		fmt.Fprintf(&b, " (%s)", strings.Join(choices, "/"))
+	}
+	if decl.Default != nil {
+		fmt.Fprintf(&b, " [%v]", decl.Default)
+	}
+	b.WriteString(": ")
+	return b.String()
+}
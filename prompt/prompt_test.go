@@ -0,0 +1,114 @@
+package prompt
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/dirtydriver/projgen/manifest"
+)
+
+// TestAsk verifies that answers are converted, defaults applied and invalid input re-prompted.
+func TestAsk(t *testing.T) {
+	tests := []struct {
+		name     string
+		decl     *manifest.Parameter
+		input    string
+		expected interface{}
+	}{
+		{
+			name:     "undeclared parameter",
+			decl:     nil,
+			input:    "demo\n",
+			expected: "demo",
+		},
+		{
+			name:     "default on empty answer",
+			decl:     &manifest.Parameter{Name: "license", Default: "MIT"},
+			input:    "\n",
+			expected: "MIT",
+		},
+		{
+			name:     "typed answer after invalid input",
+			decl:     &manifest.Parameter{Name: "replicas", Type: manifest.TypeInt},
+			input:    "many\n3\n",
+			expected: 3,
+		},
+		{
+			name:     "enum retried",
+			decl:     &manifest.Parameter{Name: "env", Enum: []interface{}{"dev", "prod"}},
+			input:    "staging\nprod",
+			expected: "prod",
+		},
+		{
+			name:     "required answer",
+			decl:     &manifest.Parameter{Name: "name"},
+			input:    "\napp\n",
+			expected: "app",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			p := New(strings.NewReader(tt.input), &out)
+			got, err := p.Ask("param", tt.decl)
+			if err != nil {
+				t.Fatalf("Ask returned error: %v", err)
+			}
+			if got != tt.expected {
+				t.Errorf("Ask() = %#v; want %#v", got, tt.expected)
+			}
+		})
+	}
+}
+
+// TestAskEOF verifies that running out of input is reported as an error.
+func TestAskEOF(t *testing.T) {
+	var out bytes.Buffer
+	p := New(strings.NewReader(""), &out)
+	if _, err := p.Ask("name", nil); err == nil {
+		t.Error("expected error on EOF, got nil")
+	}
+}
+
+// TestQuestion verifies that description, choices and default are shown.
+func TestQuestion(t *testing.T) {
+	decl := &manifest.Parameter{
+		Name:        "env",
+		Description: "Target environment",
+		Enum:        []interface{}{"dev", "prod"},
+		Default:     "dev",
+	}
+	expected := "env - Target environment (dev/prod) [dev]: "
+	if got := question("env", decl); got != expected {
+		t.Errorf("question() = %q; want %q", got, expected)
+	}
+}
+
+// TestConfirm verifies yes/no answers, the default and re-prompting.
+func TestConfirm(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{"y\n", true},
+		{"YES\n", true},
+		{"\n", false},
+		{"maybe\nn\n", false},
+	}
+	for _, tt := range tests {
+		var out bytes.Buffer
+		got, err := New(strings.NewReader(tt.input), &out).Confirm("Overwrite README.md?")
+		if err != nil {
+			t.Fatalf("Confirm(%q) returned error: %v", tt.input, err)
+		}
+		if got != tt.expected {
+			t.Errorf("Confirm(%q) = %v; want %v", tt.input, got, tt.expected)
+		}
+	}
+
+	if _, err := New(strings.NewReader(""), &bytes.Buffer{}).Confirm("Overwrite?"); err == nil {
+		t.Error("Confirm on closed input returned no error")
+	}
+}
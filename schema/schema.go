@@ -0,0 +1,162 @@
+// Package schema describes the parameters of a template as a JSON Schema.
+package schema
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/dirtydriver/projgen/manifest"
+	"github.com/dirtydriver/projgen/project"
+	"github.com/dirtydriver/projgen/utils"
+)
+
+// Draft is the JSON Schema dialect of the generated schemas.
+const Draft = "https://json-schema.org/draft/2020-12/schema"
+
+// Schema is the subset of JSON Schema used to describe template parameters.
+type Schema struct {
+	Schema               string             `json:"$schema,omitempty"`
+	Title                string             `json:"title,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Default              interface{}        `json:"default,omitempty"`
+	Enum                 []interface{}      `json:"enum,omitempty"`
+	Pattern              string             `json:"pattern,omitempty"`
+	WriteOnly            bool               `json:"writeOnly,omitempty"`
+}
+
+// jsonTypes maps manifest parameter types to JSON Schema types.
+var jsonTypes = map[string]string{
+	manifest.TypeString: "string",
+	manifest.TypeInt:    "integer",
+	manifest.TypeBool:   "boolean",
+	manifest.TypeList:   "array",
+	manifest.TypeMap:    "object",
+}
+
+// segment is a part of a parameter path; elements is set for "items[]", the elements of items,
+// and index for "items[0]", a single element of items.
+type segment struct {
+	name     string
+	elements bool
+	index    bool
+}
+
+func splitPath(path string) []segment {
+	steps, err := utils.ParsePath(path)
+	if err != nil {
+		return []segment{{name: path}}
+	}
+	var segments []segment
+	for _, step := range steps {
+		switch last := len(segments) - 1; {
+		case step.Elements:
+			segments[last].elements = true
+		case step.IsIndex:
+			segments[last].elements, segments[last].index = true, true
+		default:
+			segments = append(segments, segment{name: step.Key})
+		}
+	}
+	return segments
+}
+
+// FromParameters builds the schema of the parameter map accepted by a template.
+// Nested parameters such as project.name become nested objects and the elements of lists used as
+// items[].name become the items of an array. A parameter is required in its parent object when it
+// is required, or when it is only required under a guard that encloses it: the schema of items
+// applies only when items is set, so items[].name is required in the elements of items.
+func FromParameters(title, description string, infos []project.ParameterInfo) *Schema {
+	root := &Schema{Schema: Draft, Title: title, Description: description, Type: "object"}
+	for _, info := range infos {
+		segments := splitPath(info.Path)
+		requiredFrom := len(segments)
+		switch {
+		case info.Required:
+			requiredFrom = 0
+		case info.RequiredWhen != "" && encloses(info.RequiredWhen, info.Path):
+			requiredFrom = len(splitPath(info.RequiredWhen))
+		}
+
+		// Below a single element such as services[0] nothing is required in every element.
+		node, indexed := root, false
+		for i, seg := range segments {
+			parent := node
+			node = parent.property(seg.name)
+			if i >= requiredFrom && !indexed && !slices.Contains(parent.Required, seg.name) {
+				parent.Required = append(parent.Required, seg.name)
+			}
+			if i < len(segments)-1 || seg.elements {
+				node = node.child(seg.elements)
+			}
+			indexed = indexed || seg.index
+		}
+		node.describe(info)
+	}
+	return root
+}
+
+// encloses reports whether the guard path is path itself or a parent of it.
+func encloses(guard, path string) bool {
+	return path == guard || strings.HasPrefix(path, guard+".") || strings.HasPrefix(path, guard+"[")
+}
+
+// property returns the schema of a property of an object schema, adding it if needed.
+func (s *Schema) property(name string) *Schema {
+	if s.Type == "" {
+		s.Type = "object"
+	}
+	if s.Properties == nil {
+		s.Properties = make(map[string]*Schema)
+	}
+	p, ok := s.Properties[name]
+	if !ok {
+		p = &Schema{}
+		s.Properties[name] = p
+	}
+	return p
+}
+
+// child returns the schema the next path segment is resolved in: the schema itself for an object,
+// or the schema of its elements when elements is set. Elements are the items of an array, or the
+// values of an object declared as a map.
+func (s *Schema) child(elements bool) *Schema {
+	if !elements {
+		return s
+	}
+	if s.Type == "object" {
+		if s.AdditionalProperties == nil {
+			s.AdditionalProperties = &Schema{}
+		}
+		return s.AdditionalProperties
+	}
+	s.Type = "array"
+	if s.Items == nil {
+		s.Items = &Schema{}
+	}
+	return s.Items
+}
+
+// describe sets the type and constraints of a parameter on its schema.
+func (s *Schema) describe(info project.ParameterInfo) {
+	t, ok := jsonTypes[info.Type]
+	switch {
+	case !ok:
+	case s.Type == "":
+		s.Type = t
+	case s.Type == "array" && t == "object":
+		// A map ranged over by the templates: its values are the elements.
+		s.Type, s.AdditionalProperties, s.Items = t, s.Items, nil
+	}
+	s.Description = info.Description
+	s.Default = info.Default
+	s.Enum = info.Enum
+	s.WriteOnly = info.Secret
+	if info.Regex != "" {
+		s.Pattern = "^(?:" + info.Regex + ")$"
+	}
+}
@@ -0,0 +1,71 @@
+package schema
+
+import (
+	"encoding/json"
+	"slices"
+	"testing"
+
+	"github.com/dirtydriver/projgen/project"
+)
+
+func TestFromParameters(t *testing.T) {
+	infos := []project.ParameterInfo{
+		{Path: "items", Type: "list", Usage: "guard"},
+		{Path: "items[].id", RequiredWhen: "items", Usage: "required"},
+		{Path: "name", Type: "string", Required: true, Regex: "[a-z]+", Description: "Project name"},
+		{Path: "project.owner.email", Required: true},
+		{Path: "services", Type: "map"},
+		{Path: "services[].port", Type: "int", RequiredWhen: "services"},
+		{Path: "image", RequiredWhen: "docker"},
+		{Path: "token", Type: "string", Secret: true, Default: "x"},
+	}
+
+	got, err := json.Marshal(FromParameters("app", "Demo", infos))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `{"$schema":"https://json-schema.org/draft/2020-12/schema","title":"app","description":"Demo","type":"object",` +
+		`"properties":{` +
+		`"image":{},` +
+		`"items":{"type":"array","items":{"type":"object","properties":{"id":{}},"required":["id"]}},` +
+		`"name":{"description":"Project name","type":"string","pattern":"^(?:[a-z]+)$"},` +
+		`"project":{"type":"object","properties":{"owner":{"type":"object","properties":{"email":{}},"required":["email"]}},"required":["owner"]},` +
+		`"services":{"type":"object","additionalProperties":{"type":"object","properties":{"port":{"type":"integer"}},"required":["port"]}},` +
+		`"token":{"type":"string","default":"x","writeOnly":true}},` +
+		`"required":["name","project"]}`
+	if string(got) != expected {
+		t.Errorf("FromParameters() =\n%s\nwant\n%s", got, expected)
+	}
+}
+
+// TestFromParametersMapElements checks that a map declared after its elements were seen keeps them.
+func TestFromParametersMapElements(t *testing.T) {
+	s := FromParameters("", "", []project.ParameterInfo{
+		{Path: "services[].port", RequiredWhen: "services"},
+		{Path: "services", Type: "map"},
+	})
+	services := s.Properties["services"]
+	if services.Type != "object" || services.Items != nil || services.AdditionalProperties == nil ||
+		services.AdditionalProperties.Properties["port"] == nil {
+		t.Errorf("unexpected schema for services: %+v", services)
+	}
+}
+
+func TestFromParametersIndex(t *testing.T) {
+	s := FromParameters("", "", []project.ParameterInfo{
+		{Path: "services[0].name", Required: true},
+		{Path: `labels."app.kubernetes.io/name"`, Required: true},
+	})
+	services := s.Properties["services"]
+	if services.Type != "array" || services.Items == nil || services.Items.Properties["name"] == nil {
+		t.Fatalf("unexpected schema for services: %+v", services)
+	}
+	// Only the first service needs a name, so it is not required in every element.
+	if len(services.Items.Required) != 0 {
+		t.Errorf("services items require %v, want nothing", services.Items.Required)
+	}
+	if labels := s.Properties["labels"]; labels.Properties["app.kubernetes.io/name"] == nil ||
+		!slices.Equal(labels.Required, []string{"app.kubernetes.io/name"}) {
+		t.Errorf("unexpected schema for labels: %+v", labels)
+	}
+}
@@ -0,0 +1,125 @@
+package schema
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"slices"
+	"sort"
+
+	"github.com/dirtydriver/projgen/manifest"
+	"github.com/dirtydriver/projgen/utils"
+)
+
+// Validate checks a parameter map against the schema. It returns a manifest.FieldError, named by
+// the path of the value, for every value that does not match, joined into one error.
+func (s *Schema) Validate(params map[string]interface{}) error {
+	var errs []error
+	s.validate("", params, &errs)
+	return errors.Join(errs...)
+}
+
+func (s *Schema) validate(path string, value interface{}, errs *[]error) {
+	fail := func(format string, args ...interface{}) {
+		*errs = append(*errs, &manifest.FieldError{Field: path, Err: fmt.Errorf(format, args...)})
+	}
+
+	if s.Type != "" && !hasType(value, s.Type) {
+		fail("expected %s, got %s", s.Type, jsonType(value))
+		return
+	}
+	if len(s.Enum) > 0 && !slices.ContainsFunc(s.Enum, func(e interface{}) bool { return fmt.Sprint(e) == fmt.Sprint(value) }) {
+		fail("value %v is not one of %v", value, s.Enum)
+	}
+	if str, ok := value.(string); ok && s.Pattern != "" {
+		if re, err := regexp.Compile(s.Pattern); err == nil && !re.MatchString(str) {
+			fail("value %q does not match %s", str, s.Pattern)
+		}
+	}
+
+	switch v := value.(type) {
+	case []interface{}:
+		if s.Items != nil {
+			for i, item := range v {
+				s.Items.validate(fmt.Sprintf("%s[%d]", path, i), item, errs)
+			}
+		}
+	case map[string]interface{}:
+		for _, name := range s.Required {
+			if _, ok := v[name]; !ok {
+				*errs = append(*errs, &manifest.FieldError{Field: join(path, name), Err: errors.New("is required")})
+			}
+		}
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			if p, ok := s.Properties[k]; ok {
+				p.validate(join(path, k), v[k], errs)
+			} else if s.AdditionalProperties != nil {
+				s.AdditionalProperties.validate(join(path, k), v[k], errs)
+			}
+		}
+	}
+}
+
+func join(path, name string) string {
+	if path == "" {
+		return utils.FormatKey(name)
+	}
+	return path + "." + utils.FormatKey(name)
+}
+
+// hasType reports whether a decoded YAML value has the given JSON Schema type.
+func hasType(value interface{}, t string) bool {
+	switch t {
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "integer":
+		switch v := value.(type) {
+		case int, int64:
+			return true
+		case float64:
+			return v == math.Trunc(v)
+		}
+		return false
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "array":
+		_, ok := value.([]interface{})
+		return ok
+	case "object":
+		_, ok := value.(map[string]interface{})
+		return ok
+	}
+	return true
+}
+
+// jsonType returns the JSON Schema type of a decoded YAML value for error messages.
+func jsonType(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case int, int64:
+		return "integer"
+	case float64:
+		if v == math.Trunc(v) {
+			return "integer"
+		}
+		return "number"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return fmt.Sprintf("%T", value)
+}
@@ -0,0 +1,71 @@
+package schema
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/dirtydriver/projgen/project"
+)
+
+func TestValidate(t *testing.T) {
+	s := FromParameters("app", "", []project.ParameterInfo{
+		{Path: "items[].id", Type: "int", RequiredWhen: "items"},
+		{Path: "name", Type: "string", Required: true, Regex: "[a-z]+"},
+		{Path: "project.owner", Required: true},
+		{Path: "db", Type: "string", Enum: []interface{}{"pg", "mysql"}},
+		{Path: "replicas", Type: "int"},
+	})
+
+	tests := []struct {
+		name     string
+		params   map[string]interface{}
+		expected []string
+	}{
+		{
+			name: "valid",
+			params: map[string]interface{}{
+				"name":     "app",
+				"project":  map[string]interface{}{"owner": "me"},
+				"items":    []interface{}{map[string]interface{}{"id": float64(1)}},
+				"replicas": float64(3),
+				"db":       "pg",
+				"extra":    true,
+			},
+		},
+		{
+			name: "invalid",
+			params: map[string]interface{}{
+				"name":     "App",
+				"project":  "me",
+				"items":    []interface{}{map[string]interface{}{"id": "x"}, map[string]interface{}{}},
+				"replicas": 1.5,
+				"db":       "oracle",
+			},
+			expected: []string{
+				`db: value oracle is not one of [pg mysql]`,
+				`items[0].id: expected integer, got string`,
+				`items[1].id: is required`,
+				`name: value "App" does not match ^(?:[a-z]+)$`,
+				`project: expected object, got string`,
+				`replicas: expected integer, got number`,
+			},
+		},
+		{
+			name:     "missing",
+			params:   map[string]interface{}{},
+			expected: []string{"name: is required", "project: is required"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			if err := s.Validate(tt.params); err != nil {
+				got = strings.Split(err.Error(), "\n")
+			}
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("Validate() = %q; want %q", got, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,264 @@
+package source
+
+import (
+	"archive/zip"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/dirtydriver/projgen/filescheck"
+)
+
+// isArchive reports whether a spec names an archive or an HTTP(S) download.
+func isArchive(spec string) bool {
+	return isURL(spec) || archiveFormat(spec) != ""
+}
+
+func isURL(spec string) bool {
+	return strings.HasPrefix(spec, "http://") || strings.HasPrefix(spec, "https://")
+}
+
+// archiveFormat returns "tar.gz" or "zip" depending on the file name, or "" if it is neither.
+func archiveFormat(name string) string {
+	name = strings.ToLower(name)
+	if i := strings.IndexAny(name, "?#"); i >= 0 && isURL(name) {
+		name = name[:i]
+	}
+	switch {
+	case strings.HasSuffix(name, ".tar.gz"), strings.HasSuffix(name, ".tgz"):
+		return "tar.gz"
+	case strings.HasSuffix(name, ".zip"):
+		return "zip"
+	}
+	return ""
+}
+
+// resolveArchive makes the archive available in the content-addressed cache
+// (<cache>/archives/<sha256>) and returns the extracted tree as the source.
+func resolveArchive(spec string, opts Options, cacheDir string) (*Source, error) {
+	archivesDir := filepath.Join(cacheDir, "archives")
+	src := &Source{Spec: spec, Kind: KindArchive, URL: spec}
+	if !isURL(spec) {
+		src.URL = ""
+	}
+
+	wanted := strings.ToLower(opts.SHA256)
+	if wanted != "" {
+		if decoded, err := hex.DecodeString(wanted); err != nil || len(decoded) != sha256.Size {
+			return nil, fmt.Errorf("invalid sha256 checksum %q", opts.SHA256)
+		}
+	}
+	// A pinned checksum that is already cached needs no download at all.
+	if wanted != "" && filescheck.IsDirectoryExists(filepath.Join(archivesDir, wanted)) {
+		src.SHA256, src.Dir = wanted, filepath.Join(archivesDir, wanted)
+		return src, nil
+	}
+	indexFile := filepath.Join(archivesDir, "urls", cacheKey(spec))
+	if isURL(spec) && opts.Offline {
+		if wanted == "" {
+			if data, err := os.ReadFile(indexFile); err == nil {
+				digest := strings.TrimSpace(string(data))
+				if filescheck.IsDirectoryExists(filepath.Join(archivesDir, digest)) {
+					src.SHA256, src.Dir = digest, filepath.Join(archivesDir, digest)
+					return src, nil
+				}
+			}
+		}
+		return nil, fmt.Errorf("%s is not in the cache and --offline is set", spec)
+	}
+
+	if err := os.MkdirAll(archivesDir, 0755); err != nil {
+		return nil, err
+	}
+	archive, digest, err := fetchArchive(spec, archivesDir)
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(archive)
+
+	if wanted != "" && digest != wanted {
+		return nil, fmt.Errorf("checksum mismatch for %s: expected sha256 %s, got %s", spec, wanted, digest)
+	}
+
+	dir := filepath.Join(archivesDir, digest)
+	if !filescheck.IsDirectoryExists(dir) {
+		if err := extractArchive(archive, archiveFormat(spec), dir); err != nil {
+			return nil, fmt.Errorf("extracting %s: %w", spec, err)
+		}
+	}
+	if isURL(spec) {
+		if err := os.MkdirAll(filepath.Dir(indexFile), 0755); err != nil {
+			return nil, err
+		}
+		if err := os.WriteFile(indexFile, []byte(digest+"\n"), 0644); err != nil {
+			return nil, err
+		}
+	}
+	src.SHA256, src.Dir = digest, dir
+	return src, nil
+}
+
+// httpClient downloads remote archives. The timeout covers the whole download, so that an
+// unresponsive server cannot block generation forever.
+var httpClient = &http.Client{Timeout: 5 * time.Minute}
+
+// fetchArchive copies a local or remote archive into a temporary file in dir and returns
+// its path together with the hex encoded SHA-256 of its content.
+func fetchArchive(spec, dir string) (string, string, error) {
+	var body io.ReadCloser
+	if isURL(spec) {
+		resp, err := httpClient.Get(spec)
+		if err != nil {
+			return "", "", fmt.Errorf("downloading %s: %w", spec, err)
+		}
+		if resp.StatusCode != http.StatusOK {
+			resp.Body.Close()
+			return "", "", fmt.Errorf("downloading %s: %s", spec, resp.Status)
+		}
+		body = resp.Body
+	} else {
+		f, err := os.Open(spec)
+		if err != nil {
+			return "", "", err
+		}
+		body = f
+	}
+	defer body.Close()
+
+	tmp, err := os.CreateTemp(dir, "download-")
+	if err != nil {
+		return "", "", err
+	}
+	hash := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(tmp, hash), body); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return "", "", fmt.Errorf("reading %s: %w", spec, err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return "", "", err
+	}
+	return tmp.Name(), hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// extractArchive extracts a tar.gz or zip archive into dir via a temporary directory,
+// so that an interrupted extraction never leaves a partial cache entry.
+func extractArchive(archive, format, dir string) error {
+	tmp, err := os.MkdirTemp(filepath.Dir(dir), "tmp-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmp)
+
+	if format == "" {
+		if format, err = sniffFormat(archive); err != nil {
+			return err
+		}
+	}
+	switch format {
+	case "tar.gz":
+		err = extractTarGz(archive, tmp)
+	case "zip":
+		err = extractZip(archive, tmp)
+	default:
+		err = errors.New("unsupported archive format, expected .tar.gz, .tgz or .zip")
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp, dir)
+}
+
+// sniffFormat detects the archive format of downloads whose URL does not reveal it.
+func sniffFormat(archive string) (string, error) {
+	f, err := os.Open(archive)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	magic := make([]byte, 4)
+	if _, err := io.ReadFull(f, magic); err != nil {
+		return "", nil
+	}
+	switch {
+	case magic[0] == 0x1f && magic[1] == 0x8b:
+		return "tar.gz", nil
+	case string(magic) == "PK\x03\x04":
+		return "zip", nil
+	}
+	return "", nil
+}
+
+func extractTarGz(archive, dest string) error {
+	f, err := os.Open(archive)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return err
+	}
+	defer gz.Close()
+	return extractTar(gz, dest)
+}
+
+func extractZip(archive, dest string) error {
+	zr, err := zip.OpenReader(archive)
+	if err != nil {
+		return err
+	}
+	defer zr.Close()
+
+	for _, zf := range zr.File {
+		target, err := safeJoin(dest, zf.Name)
+		if err != nil {
+			return err
+		}
+		mode := zf.Mode()
+		switch {
+		case mode.IsDir():
+			err = os.MkdirAll(target, 0755)
+		case mode&os.ModeSymlink != 0:
+			err = extractZipSymlink(zf, dest, target)
+		case mode.IsRegular():
+			err = extractZipFile(zf, target)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func extractZipFile(zf *zip.File, target string) error {
+	rc, err := zf.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	return writeFile(target, rc, zf.Mode().Perm())
+}
+
+// extractZipSymlink recreates a symlink stored in a zip archive, where the content is the link target.
+func extractZipSymlink(zf *zip.File, dest, target string) error {
+	rc, err := zf.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	linkname, err := io.ReadAll(rc)
+	if err != nil {
+		return err
+	}
+	return symlink(dest, target, string(linkname))
+}
@@ -0,0 +1,146 @@
+package source
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// tarGzArchive returns a gzipped tar archive containing maven/pom.xml.tmpl.
+func tarGzArchive(t *testing.T) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	if _, err := tarArchive(t, &tar.Header{Name: "maven/pom.xml.tmpl", Typeflag: tar.TypeReg, Mode: 0644}).WriteTo(gz); err != nil {
+		t.Fatalf("failed to compress archive: %v", err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatalf("failed to close gzip writer: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func checksum(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// TestResolveLocalArchives verifies extraction of local tar.gz and zip archives into the cache.
+func TestResolveLocalArchives(t *testing.T) {
+	dir := t.TempDir()
+
+	tgz := filepath.Join(dir, "templates.tar.gz")
+	if err := os.WriteFile(tgz, tarGzArchive(t), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var zipBuf bytes.Buffer
+	zw := zip.NewWriter(&zipBuf)
+	w, err := zw.Create("maven/pom.xml.tmpl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write([]byte("maven/pom.xml.tmpl")); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	zipPath := filepath.Join(dir, "templates.zip")
+	if err := os.WriteFile(zipPath, zipBuf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	opts := Options{CacheDir: t.TempDir()}
+	for _, spec := range []string{tgz, zipPath} {
+		src, err := Resolve(spec, opts)
+		if err != nil {
+			t.Fatalf("Resolve(%s) returned error: %v", spec, err)
+		}
+		data, _ := os.ReadFile(spec)
+		if src.Kind != KindArchive || src.SHA256 != checksum(data) {
+			t.Errorf("unexpected source: %+v", src)
+		}
+		if src.Dir != filepath.Join(opts.CacheDir, "archives", src.SHA256) {
+			t.Errorf("expected content-addressed cache directory, got %s", src.Dir)
+		}
+		if _, err := os.Stat(filepath.Join(src.Dir, "maven", "pom.xml.tmpl")); err != nil {
+			t.Errorf("expected extracted template: %v", err)
+		}
+	}
+}
+
+// TestResolveArchiveChecksum verifies checksum pinning.
+func TestResolveArchiveChecksum(t *testing.T) {
+	archive := filepath.Join(t.TempDir(), "templates.tgz")
+	data := tarGzArchive(t)
+	if err := os.WriteFile(archive, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	opts := Options{CacheDir: t.TempDir()}
+
+	opts.SHA256 = strings.Repeat("0", 64)
+	if _, err := Resolve(archive, opts); err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+		t.Errorf("expected checksum mismatch, got %v", err)
+	}
+	opts.SHA256 = "../../etc"
+	if _, err := Resolve(archive, opts); err == nil {
+		t.Error("expected error for invalid checksum, got nil")
+	}
+	opts.SHA256 = strings.ToUpper(checksum(data))
+	if _, err := Resolve(archive, opts); err != nil {
+		t.Errorf("Resolve returned error for matching checksum: %v", err)
+	}
+}
+
+// TestResolveHTTPArchive verifies downloads, the offline cache and format sniffing.
+func TestResolveHTTPArchive(t *testing.T) {
+	data := tarGzArchive(t)
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.URL.Path == "/missing" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write(data)
+	}))
+	defer server.Close()
+
+	opts := Options{CacheDir: t.TempDir()}
+	spec := server.URL + "/download?id=1"
+
+	if _, err := Resolve(spec, Options{CacheDir: opts.CacheDir, Offline: true}); err == nil {
+		t.Error("expected offline error before the archive was cached, got nil")
+	}
+
+	src, err := Resolve(spec, opts)
+	if err != nil {
+		t.Fatalf("Resolve returned error: %v", err)
+	}
+	if src.URL != spec || src.SHA256 != checksum(data) {
+		t.Errorf("unexpected source: %+v", src)
+	}
+
+	before := requests
+	cached, err := Resolve(spec, Options{CacheDir: opts.CacheDir, Offline: true})
+	if err != nil {
+		t.Fatalf("offline Resolve returned error: %v", err)
+	}
+	if cached.Dir != src.Dir || requests != before {
+		t.Errorf("expected offline resolution from cache without downloading, got %+v", cached)
+	}
+
+	if _, err := Resolve(server.URL+"/missing", opts); err == nil {
+		t.Error("expected error for failed download, got nil")
+	}
+}
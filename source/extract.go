@@ -0,0 +1,114 @@
+package source
+
+import (
+	"archive/tar"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/dirtydriver/projgen/filescheck"
+)
+
+// safeJoin joins an archive member name to the destination directory and rejects names that
+// would end up outside of it ("zip slip"), either lexically or by passing through a symbolic link
+// extracted before, such as "d/l/f" after "d/l -> ..".
+func safeJoin(dest, name string) (string, error) {
+	target := filepath.Join(dest, filepath.FromSlash(name))
+	if !filescheck.Within(dest, target) || filepath.IsAbs(name) {
+		return "", fmt.Errorf("archive entry %q escapes the destination directory", name)
+	}
+	inside, err := filescheck.ResolvesWithin(dest, target)
+	if err != nil {
+		return "", err
+	}
+	if !inside {
+		return "", fmt.Errorf("archive entry %q escapes the destination directory through a symbolic link", name)
+	}
+	return target, nil
+}
+
+// extractTar extracts a tar stream into dest. Symbolic links must point inside dest;
+// other special files are skipped.
+func extractTar(r io.Reader, dest string) error {
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		target, err := safeJoin(dest, hdr.Name)
+		if err != nil {
+			return err
+		}
+
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := writeFile(target, tr, hdr.FileInfo().Mode().Perm()); err != nil {
+				return err
+			}
+		case tar.TypeSymlink:
+			if err := symlink(dest, target, hdr.Linkname); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// writeFile writes the content of r to path, creating parent directories as needed.
+func writeFile(path string, r io.Reader, mode os.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	// Archives created on some systems carry no permission bits at all.
+	if mode == 0 {
+		mode = 0644
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode|0200)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// symlink creates a symbolic link at target whose destination must stay inside dest, also when
+// it is reached through links extracted before.
+func symlink(dest, target, linkname string) error {
+	resolved := linkname
+	if !filepath.IsAbs(resolved) {
+		resolved = filepath.Join(filepath.Dir(target), linkname)
+	}
+	if !filescheck.Within(dest, resolved) {
+		return fmt.Errorf("archive symlink %s -> %s escapes the destination directory", target, linkname)
+	}
+	if !filepath.IsAbs(linkname) {
+		parent, err := filescheck.RealPath(filepath.Dir(target))
+		if err != nil {
+			return err
+		}
+		resolved = parent + string(filepath.Separator) + filepath.FromSlash(linkname)
+	}
+	inside, err := filescheck.ResolvesWithin(dest, resolved)
+	if err != nil {
+		return err
+	}
+	if !inside {
+		return fmt.Errorf("archive symlink %s -> %s escapes the destination directory through a symbolic link", target, linkname)
+	}
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+	return os.Symlink(linkname, target)
+}
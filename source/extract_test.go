@@ -0,0 +1,107 @@
+package source
+
+import (
+	"archive/tar"
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// tarArchive builds a tar stream from the given headers, using the name as file content.
+func tarArchive(t *testing.T, headers ...*tar.Header) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for _, hdr := range headers {
+		var content []byte
+		if hdr.Typeflag == tar.TypeReg {
+			content = []byte(hdr.Name)
+			hdr.Size = int64(len(content))
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatalf("failed to write header: %v", err)
+		}
+		if _, err := tw.Write(content); err != nil {
+			t.Fatalf("failed to write content: %v", err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatalf("failed to close archive: %v", err)
+	}
+	return &buf
+}
+
+// TestExtractTar verifies extraction of files, directories and symlinks.
+func TestExtractTar(t *testing.T) {
+	dest := t.TempDir()
+	archive := tarArchive(t,
+		&tar.Header{Name: "maven/", Typeflag: tar.TypeDir, Mode: 0755},
+		&tar.Header{Name: "maven/mvnw", Typeflag: tar.TypeReg, Mode: 0755},
+		&tar.Header{Name: "maven/link", Typeflag: tar.TypeSymlink, Linkname: "mvnw"},
+	)
+	if err := extractTar(archive, dest); err != nil {
+		t.Fatalf("extractTar returned error: %v", err)
+	}
+	info, err := os.Stat(filepath.Join(dest, "maven", "mvnw"))
+	if err != nil {
+		t.Fatalf("expected extracted file: %v", err)
+	}
+	if info.Mode().Perm()&0100 == 0 {
+		t.Errorf("expected executable mode, got %v", info.Mode())
+	}
+	if target, err := os.Readlink(filepath.Join(dest, "maven", "link")); err != nil || target != "mvnw" {
+		t.Errorf("expected symlink to mvnw, got %q, %v", target, err)
+	}
+}
+
+// TestExtractTarZipSlip verifies that entries escaping the destination are rejected.
+func TestExtractTarZipSlip(t *testing.T) {
+	tests := []*tar.Header{
+		{Name: "../evil", Typeflag: tar.TypeReg, Mode: 0644},
+		{Name: "a/../../evil", Typeflag: tar.TypeReg, Mode: 0644},
+		{Name: "link", Typeflag: tar.TypeSymlink, Linkname: "../../etc/passwd"},
+		{Name: "abs", Typeflag: tar.TypeSymlink, Linkname: "/etc/passwd"},
+	}
+	for _, hdr := range tests {
+		dest := t.TempDir()
+		if err := extractTar(tarArchive(t, hdr), dest); err == nil {
+			t.Errorf("expected error for %s, got nil", hdr.Name)
+		}
+	}
+}
+
+// TestExtractTarSymlinkEscape verifies that links extracted before cannot be used to write or link
+// outside the destination.
+func TestExtractTarSymlinkEscape(t *testing.T) {
+	tests := map[string][]*tar.Header{
+		"file below chained links": {
+			{Name: "d/l", Typeflag: tar.TypeSymlink, Linkname: ".."},
+			{Name: "d/l/l2", Typeflag: tar.TypeSymlink, Linkname: ".."},
+			{Name: "d/l/l2/ESCAPED", Typeflag: tar.TypeReg, Mode: 0644},
+		},
+		"link through a link": {
+			{Name: "d/l", Typeflag: tar.TypeSymlink, Linkname: ".."},
+			{Name: "d/l2", Typeflag: tar.TypeSymlink, Linkname: "l/.."},
+		},
+		"directory below a link": {
+			{Name: "l", Typeflag: tar.TypeSymlink, Linkname: "."},
+			{Name: "m", Typeflag: tar.TypeSymlink, Linkname: "l/.."},
+			{Name: "m/dir/", Typeflag: tar.TypeDir, Mode: 0755},
+		},
+	}
+	for name, headers := range tests {
+		root := t.TempDir()
+		dest := filepath.Join(root, "dest")
+		if err := os.Mkdir(dest, 0755); err != nil {
+			t.Fatalf("failed to create directory: %v", err)
+		}
+		if err := extractTar(tarArchive(t, headers...), dest); err == nil {
+			t.Errorf("%s: expected error, got nil", name)
+		}
+		entries, _ := os.ReadDir(root)
+		if len(entries) != 1 {
+			t.Errorf("%s: extraction wrote outside the destination: %v", name, entries)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package source
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/dirtydriver/projgen/filescheck"
+)
+
+// resolveGit mirrors the repository into the cache, resolves the ref to a commit and
+// extracts that commit into a cache directory named after it.
+// In offline mode an existing mirror is used without updating it.
+func resolveGit(spec, url, ref, cacheDir string, offline bool) (*Source, error) {
+	// Neither is passed to git where it could be taken for an option.
+	if strings.HasPrefix(url, "-") {
+		return nil, fmt.Errorf("invalid git URL %q", url)
+	}
+	if strings.HasPrefix(ref, "-") {
+		return nil, fmt.Errorf("invalid git ref %q", ref)
+	}
+	mirror := filepath.Join(cacheDir, "git", cacheKey(url)+".git")
+	switch {
+	case filescheck.IsDirectoryExists(mirror) && offline:
+	case filescheck.IsDirectoryExists(mirror):
+		if _, err := git("--git-dir", mirror, "remote", "update", "--prune"); err != nil {
+			return nil, fmt.Errorf("updating %s: %w", url, err)
+		}
+	case offline:
+		return nil, fmt.Errorf("%s is not in the cache and --offline is set", url)
+	default:
+		if err := os.MkdirAll(filepath.Dir(mirror), 0755); err != nil {
+			return nil, err
+		}
+		if _, err := git("clone", "--quiet", "--mirror", "--", url, mirror); err != nil {
+			return nil, fmt.Errorf("cloning %s: %w", url, err)
+		}
+	}
+
+	rev := ref
+	if rev == "" {
+		rev = "HEAD"
+	}
+	// rev-parse reads arguments after "--" as paths, --end-of-options ends the options instead.
+	commit, err := git("--git-dir", mirror, "rev-parse", "--verify", "--quiet", "--end-of-options", rev+"^{commit}")
+	if err != nil {
+		return nil, fmt.Errorf("unknown ref %s in %s", rev, url)
+	}
+
+	dir := filepath.Join(cacheDir, "git", "checkouts", commit)
+	if !filescheck.IsDirectoryExists(dir) {
+		if err := checkout(mirror, commit, dir); err != nil {
+			return nil, fmt.Errorf("checking out %s of %s: %w", commit, url, err)
+		}
+	}
+	return &Source{Spec: spec, Kind: KindGit, Dir: dir, URL: url, Ref: ref, Commit: commit}, nil
+}
+
+// checkout extracts the tree of a commit into dir. The tree is written to a temporary
+// directory first so that an interrupted checkout never leaves a partial cache entry.
+func checkout(mirror, commit, dir string) error {
+	if err := os.MkdirAll(filepath.Dir(dir), 0755); err != nil {
+		return err
+	}
+	tmp, err := os.MkdirTemp(filepath.Dir(dir), "tmp-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmp)
+
+	cmd := exec.Command("git", "--git-dir", mirror, "archive", "--format=tar", commit)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	extractErr := extractTar(stdout, tmp)
+	if err := cmd.Wait(); err != nil {
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	if extractErr != nil {
+		return extractErr
+	}
+	return os.Rename(tmp, dir)
+}
+
+// git runs a git command and returns its trimmed standard output.
+func git(args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("%w: %s", err, msg)
+		}
+		return "", err
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
@@ -0,0 +1,138 @@
+package source
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Kinds of template sources.
+const (
+	KindDir     = "dir"
+	KindGit     = "git"
+	KindArchive = "archive"
+)
+
+// Source is a template root resolved to a local directory.
+type Source struct {
+	// Spec is the --template-dir value the source was resolved from.
+	Spec string `json:"spec"`
+	// Kind is one of the Kind constants.
+	Kind string `json:"kind"`
+	// Dir is the local directory containing the template types.
+	Dir string `json:"-"`
+	// URL is the location of a remote source, without the ref.
+	URL string `json:"url,omitempty"`
+	// Ref is the requested git ref; empty means the default branch.
+	Ref string `json:"ref,omitempty"`
+	// Commit is the resolved git commit hash.
+	Commit string `json:"commit,omitempty"`
+	// SHA256 is the hex encoded checksum of an archive.
+	SHA256 string `json:"sha256,omitempty"`
+}
+
+// Options control how remote sources are fetched.
+type Options struct {
+	// CacheDir holds fetched sources. It defaults to projgen in the user cache directory.
+	CacheDir string
+	// SHA256 pins the expected checksum of an archive.
+	SHA256 string
+	// Offline only uses what is already in the cache.
+	Offline bool
+}
+
+// String describes the source including the exact version used.
+func (s *Source) String() string {
+	switch s.Kind {
+	case KindGit:
+		ref := s.Ref
+		if ref == "" {
+			ref = "HEAD"
+		}
+		return fmt.Sprintf("%s@%s (commit %s)", s.URL, ref, s.Commit)
+	case KindArchive:
+		return fmt.Sprintf("%s (sha256 %s)", s.Spec, s.SHA256)
+	default:
+		return s.Dir
+	}
+}
+
+// Resolve turns a --template-dir value into a local template root.
+// Plain paths are used as they are. Git repositories are given as git+<url>[@ref]
+// (e.g. git+file:///srv/templates.git@v2.3.0) or as plain git URLs ending in .git or using the
+// git@host:path, ssh:// and git:// forms; the ref is checked out into the cache directory.
+// Local .tar.gz, .tgz and .zip archives and other HTTP(S) URLs are downloaded, verified against
+// Options.SHA256 when set, and extracted into a cache directory named after their checksum.
+func Resolve(spec string, opts Options) (*Source, error) {
+	if url, ref, ok := parseGitSpec(spec); ok {
+		cacheDir, err := opts.cacheDir()
+		if err != nil {
+			return nil, err
+		}
+		return resolveGit(spec, url, ref, cacheDir, opts.Offline)
+	}
+	if isArchive(spec) {
+		cacheDir, err := opts.cacheDir()
+		if err != nil {
+			return nil, err
+		}
+		return resolveArchive(spec, opts, cacheDir)
+	}
+	if opts.SHA256 != "" {
+		return nil, fmt.Errorf("a checksum can only be verified for archives, %s is a directory", spec)
+	}
+
+	info, err := os.Stat(spec)
+	if err != nil {
+		return nil, fmt.Errorf("template directory %s: %w", spec, err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("template directory %s is not a directory", spec)
+	}
+	return &Source{Spec: spec, Kind: KindDir, Dir: spec}, nil
+}
+
+func (o Options) cacheDir() (string, error) {
+	if o.CacheDir != "" {
+		return o.CacheDir, nil
+	}
+	userCache, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("determining cache directory: %w", err)
+	}
+	return filepath.Join(userCache, "projgen"), nil
+}
+
+// parseGitSpec recognises git sources and splits them into URL and ref.
+func parseGitSpec(spec string) (url, ref string, ok bool) {
+	url = spec
+	explicit := strings.HasPrefix(url, "git+")
+	url = strings.TrimPrefix(url, "git+")
+
+	// The ref follows the last "@" unless that "@" belongs to the user part of the URL.
+	if i := strings.LastIndex(url, "@"); i > 0 {
+		before, after := url[:i], url[i+1:]
+		if strings.HasSuffix(before, ".git") || !strings.ContainsAny(after, ":/") {
+			url, ref = before, after
+		}
+	}
+
+	isGit := explicit ||
+		strings.HasSuffix(url, ".git") ||
+		strings.HasPrefix(url, "git@") ||
+		strings.HasPrefix(url, "ssh://") ||
+		strings.HasPrefix(url, "git://")
+	if !isGit {
+		return "", "", false
+	}
+	return url, ref, true
+}
+
+// cacheKey returns a stable directory name for a source location.
+func cacheKey(location string) string {
+	sum := sha256.Sum256([]byte(location))
+	return hex.EncodeToString(sum[:])[:16]
+}
@@ -0,0 +1,120 @@
+package source
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestParseGitSpec verifies recognition of git sources and ref splitting.
+func TestParseGitSpec(t *testing.T) {
+	tests := []struct {
+		spec  string
+		url   string
+		ref   string
+		isGit bool
+	}{
+		{"./templates", "", "", false},
+		{"/srv/templates", "", "", false},
+		{"git+file:///srv/templates.git@v2.3.0", "file:///srv/templates.git", "v2.3.0", true},
+		{"git+file:///srv/templates", "file:///srv/templates", "", true},
+		{"https://example.com/org/templates.git", "https://example.com/org/templates.git", "", true},
+		{"https://example.com/org/templates.git@feature/x", "https://example.com/org/templates.git", "feature/x", true},
+		{"git@github.com:org/templates.git", "git@github.com:org/templates.git", "", true},
+		{"git@github.com:org/templates.git@main", "git@github.com:org/templates.git", "main", true},
+		{"ssh://git@example.com/org/templates", "ssh://git@example.com/org/templates", "", true},
+		{"git+https://user@example.com/templates@abc123", "https://user@example.com/templates", "abc123", true},
+	}
+
+	for _, tt := range tests {
+		url, ref, ok := parseGitSpec(tt.spec)
+		if ok != tt.isGit || url != tt.url || ref != tt.ref {
+			t.Errorf("parseGitSpec(%q) = %q, %q, %v; want %q, %q, %v", tt.spec, url, ref, ok, tt.url, tt.ref, tt.isGit)
+		}
+	}
+}
+
+// TestResolveDir verifies that plain directories are used as they are.
+func TestResolveDir(t *testing.T) {
+	dir := t.TempDir()
+	src, err := Resolve(dir, Options{})
+	if err != nil {
+		t.Fatalf("Resolve returned error: %v", err)
+	}
+	if src.Kind != KindDir || src.Dir != dir {
+		t.Errorf("unexpected source: %+v", src)
+	}
+	if _, err := Resolve(filepath.Join(dir, "missing"), Options{}); err == nil {
+		t.Error("expected error for missing directory, got nil")
+	}
+}
+
+// runGit runs git in dir and fails the test on error.
+func runGit(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	args = append([]string{"-C", dir, "-c", "user.name=test", "-c", "user.email=test@example.com", "-c", "commit.gpgsign=false"}, args...)
+	out, err := exec.Command("git", args...).CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %v failed: %v: %s", args, err, out)
+	}
+	return string(out)
+}
+
+// TestResolveGit verifies that a ref of a local repository is checked out into the cache.
+func TestResolveGit(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	repo := t.TempDir()
+	runGit(t, repo, "init", "--quiet")
+	if err := os.MkdirAll(filepath.Join(repo, "maven"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(repo, "maven", "pom.xml.tmpl"), []byte("v1"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	runGit(t, repo, "add", "-A")
+	runGit(t, repo, "commit", "--quiet", "-m", "v1")
+	runGit(t, repo, "tag", "v1.0.0")
+	if err := os.WriteFile(filepath.Join(repo, "maven", "pom.xml.tmpl"), []byte("v2"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	runGit(t, repo, "commit", "--quiet", "-am", "v2")
+
+	opts := Options{CacheDir: t.TempDir()}
+	tests := []struct {
+		spec     string
+		ref      string
+		expected string
+	}{
+		{"git+file://" + filepath.ToSlash(repo) + "@v1.0.0", "v1.0.0", "v1"},
+		{"git+file://" + filepath.ToSlash(repo), "", "v2"},
+	}
+	for _, tt := range tests {
+		src, err := Resolve(tt.spec, opts)
+		if err != nil {
+			t.Fatalf("Resolve(%q) returned error: %v", tt.spec, err)
+		}
+		if src.Kind != KindGit || src.Ref != tt.ref || len(src.Commit) != 40 {
+			t.Errorf("unexpected source: %+v", src)
+		}
+		data, err := os.ReadFile(filepath.Join(src.Dir, "maven", "pom.xml.tmpl"))
+		if err != nil {
+			t.Fatalf("failed to read checked out file: %v", err)
+		}
+		if string(data) != tt.expected {
+			t.Errorf("checked out %q, want %q", data, tt.expected)
+		}
+	}
+
+	if _, err := Resolve("git+file://"+filepath.ToSlash(repo)+"@v9.9.9", opts); err == nil {
+		t.Error("expected error for unknown ref, got nil")
+	}
+	for _, spec := range []string{"git+--upload-pack=touch pwned", "git+file://" + filepath.ToSlash(repo) + "@--output=pwned"} {
+		if _, err := Resolve(spec, opts); err == nil || !strings.Contains(err.Error(), "invalid git") {
+			t.Errorf("Resolve(%s) = %v, want an invalid git URL or ref error", spec, err)
+		}
+	}
+}
@@ -0,0 +1,427 @@
+package templater
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"text/template"
+	"text/template/parse"
+
+	"github.com/dirtydriver/projgen/utils"
+)
+
+// value is what the analyser knows about a value in a template: the parameter path it was read from.
+// The empty path is the root parameter map. Values computed by functions are not known.
+type value struct {
+	path  string
+	known bool
+}
+
+var unknown = value{}
+
+// field returns the value reached by accessing the given fields of v.
+func (v value) field(ident ...string) value {
+	if !v.known || len(ident) == 0 {
+		return v
+	}
+	if v.path == "" {
+		return value{path: strings.Join(ident, "."), known: true}
+	}
+	return value{path: v.path + "." + strings.Join(ident, "."), known: true}
+}
+
+// elem returns the value of the elements of v when it is ranged over, e.g. "items[]" for "items".
+func (v value) elem() value {
+	if !v.known || v.path == "" {
+		return unknown
+	}
+	return value{path: v.path + "[]", known: true}
+}
+
+// at returns the element at index i of v when it is a list, e.g. "services[0]" for "services".
+func (v value) at(i int) value {
+	if !v.known || v.path == "" {
+		return unknown
+	}
+	return value{path: fmt.Sprintf("%s[%d]", v.path, i), known: true}
+}
+
+// key returns the value stored under a map key of v. Keys that are not plain, such as
+// "app.kubernetes.io/name", are quoted in the path.
+func (v value) key(k string) value {
+	return v.field(utils.FormatKey(k))
+}
+
+// scope holds the value of dot and of the variables at a point of a template, and the parameter
+// paths guarding it: the values tested by the enclosing {{ if }}, {{ with }} and {{ range }} blocks.
+// The guard of an {{ else }} branch is the path prefixed with "!", as it only runs when the value is not set.
+type scope struct {
+	dot    value
+	vars   map[string]value
+	guards []string
+}
+
+func newScope(dot value, guards []string) *scope {
+	return &scope{dot: dot, vars: map[string]value{"$": dot}, guards: guards}
+}
+
+// child returns a copy of the scope for a nested block, so that variables declared inside do not leak out.
+func (s *scope) child() *scope {
+	vars := make(map[string]value, len(s.vars))
+	for k, v := range s.vars {
+		vars[k] = v
+	}
+	return &scope{dot: s.dot, vars: vars, guards: slices.Clip(s.guards)}
+}
+
+// guard adds the value tested by a block to the guards of its body.
+func (s *scope) guard(v value) {
+	if v.known && v.path != "" {
+		s.guards = append(s.guards, v.path)
+	}
+}
+
+// within returns the innermost guard of the scope, if any: the body of {{ if .docker }} only runs
+// when docker is set and the body of {{ range .items }} only when items has elements.
+func (s *scope) within() string {
+	if len(s.guards) == 0 {
+		return ""
+	}
+	return s.guards[len(s.guards)-1]
+}
+
+// reference is a single use of a parameter in a template.
+type reference struct {
+	path   string
+	usage  string
+	def    interface{}
+	within string
+	// ranged is set for the value iterated by a {{ range }}.
+	ranged bool
+	// file and line locate the reference; line is zero for references in a templated file name.
+	// The analyzer sets file to the name of the template the reference was parsed in.
+	file string
+	line int
+}
+
+// analyzer walks the parse trees of a template and reports every parameter path it references.
+// Fields are resolved against the current dot, so inside {{ range .items }} the field .name is
+// reported as items[].name and inside {{ with .project }} the field .name as project.name.
+// Variables, including $ for the root, are tracked through declarations and assignments, and
+// templates invoked with {{ template }} are analysed with the data passed to them.
+//
+// Each reference is classified: parameters tested by {{ if }}, {{ with }} or {{ range }} are guards,
+// parameters passed to the default function are optional, and all others are required.
+type analyzer struct {
+	set  *template.Template
+	tree *parse.Tree
+	// report is called for every parameter reference.
+	report func(reference)
+	// condition is set while walking the pipeline of a block, optional while walking an argument
+	// that the default function replaces with def when it is empty.
+	condition bool
+	optional  bool
+	def       interface{}
+	// visited holds the templates already analysed per dot and active the templates being analysed,
+	// so that shared templates are walked once per dot and recursive templates are not followed.
+	visited map[string]bool
+	active  map[string]bool
+	invoked map[string]bool
+}
+
+func newAnalyzer(set *template.Template, report func(reference)) *analyzer {
+	return &analyzer{set: set, report: report, visited: make(map[string]bool), active: make(map[string]bool), invoked: make(map[string]bool)}
+}
+
+// analyze walks the named template with the root parameter map as dot, followed by the templates
+// defined in the set that it does not invoke, e.g. blocks overriding those of a parent template.
+func (a *analyzer) analyze(name string) {
+	a.invoke(name, value{known: true}, nil)
+	for _, t := range a.set.Templates() {
+		if !a.invoked[t.Name()] {
+			a.invoke(t.Name(), value{known: true}, nil)
+		}
+	}
+}
+
+func (a *analyzer) invoke(name string, dot value, guards []string) {
+	a.invoked[name] = true
+	t := a.set.Lookup(name)
+	if t == nil || t.Tree == nil {
+		return
+	}
+	key := name + "\x00" + dot.path
+	if !dot.known {
+		key = name + "\x00?"
+	}
+	if a.visited[key] || a.active[name] {
+		return
+	}
+	a.visited[key] = true
+	a.active[name] = true
+	caller := a.tree
+	a.tree = t.Tree
+	a.walkList(t.Tree.Root, newScope(dot, guards))
+	a.tree = caller
+	delete(a.active, name)
+}
+
+func (a *analyzer) walkList(list *parse.ListNode, s *scope) {
+	if list == nil {
+		return
+	}
+	for _, node := range list.Nodes {
+		a.walk(node, s)
+	}
+}
+
+func (a *analyzer) walk(node parse.Node, s *scope) {
+	switch n := node.(type) {
+	case *parse.ActionNode:
+		a.declare(n.Pipe, a.pipe(n.Pipe, s), s)
+	case *parse.IfNode:
+		a.branch(&n.BranchNode, s, func(v value, inner *scope) {
+			a.declare(n.Pipe, v, inner)
+			inner.guard(v)
+		})
+	case *parse.WithNode:
+		a.branch(&n.BranchNode, s, func(v value, inner *scope) {
+			a.declare(n.Pipe, v, inner)
+			inner.guard(v)
+			inner.dot = v
+		})
+	case *parse.RangeNode:
+		a.branch(&n.BranchNode, s, func(v value, inner *scope) {
+			if v.known && v.path != "" {
+				a.report(reference{path: v.path, usage: UsageGuard, within: inner.within(), ranged: true, file: a.tree.ParseName, line: a.line(n)})
+			}
+			elem := v.elem()
+			switch len(n.Pipe.Decl) {
+			case 1:
+				inner.vars[n.Pipe.Decl[0].Ident[0]] = elem
+			case 2:
+				inner.vars[n.Pipe.Decl[0].Ident[0]] = unknown
+				inner.vars[n.Pipe.Decl[1].Ident[0]] = elem
+			}
+			inner.guard(v)
+			inner.dot = elem
+		})
+	case *parse.TemplateNode:
+		dot := unknown
+		if n.Pipe != nil {
+			dot = a.pipe(n.Pipe, s)
+		}
+		a.invoke(n.Name, dot, s.guards)
+	case *parse.ListNode:
+		a.walkList(n, s)
+	}
+}
+
+// branch walks an if, with or range node. enter sets up the scope of the body from the value of the pipeline;
+// the else branch sees the enclosing scope.
+func (a *analyzer) branch(n *parse.BranchNode, s *scope, enter func(v value, inner *scope)) {
+	inner := s.child()
+	a.condition = true
+	v := a.pipe(n.Pipe, inner)
+	a.condition = false
+	enter(v, inner)
+	a.walkList(n.List, inner)
+
+	// Variables declared in the pipeline are visible in the else branch too; dot is not changed there
+	// and the branch is guarded by the negated value.
+	outer := inner.child()
+	outer.dot = s.dot
+	outer.guards = s.guards
+	if v.known && v.path != "" {
+		outer.guards = append(slices.Clip(s.guards), "!"+v.path)
+	}
+	a.walkList(n.ElseList, outer)
+}
+
+// declare records the variables declared or assigned by an action's pipeline.
+func (a *analyzer) declare(pipe *parse.PipeNode, v value, s *scope) {
+	for _, variable := range pipe.Decl {
+		s.vars[variable.Ident[0]] = v
+	}
+}
+
+// pipe walks all commands of a pipeline and returns its value, which is only known when the
+// pipeline consists of a single field, variable or dot.
+func (a *analyzer) pipe(pipe *parse.PipeNode, s *scope) value {
+	if pipe == nil {
+		return unknown
+	}
+	result := unknown
+	for i, cmd := range pipe.Cmds {
+		if v, ok := a.index(cmd, s); ok {
+			result = v
+			continue
+		}
+		result = unknown
+		for j, arg := range cmd.Args {
+			// {{ default "x" .a }} and {{ .a | default "x" }} make a optional.
+			optional, def := false, interface{}(nil)
+			if isDefault(cmd) && len(cmd.Args) == 3 && j == 2 {
+				optional, def = true, literal(cmd.Args[1])
+			} else if i+1 < len(pipe.Cmds) && isDefault(pipe.Cmds[i+1]) && len(pipe.Cmds[i+1].Args) == 2 && len(cmd.Args) == 1 {
+				optional, def = true, literal(pipe.Cmds[i+1].Args[1])
+			}
+			saved, savedDef := a.optional, a.def
+			if optional {
+				a.optional, a.def = true, def
+			}
+			v := a.arg(arg, s)
+			a.optional, a.def = saved, savedDef
+			if len(cmd.Args) == 1 {
+				result = v
+			}
+		}
+	}
+	if len(pipe.Cmds) != 1 {
+		return unknown
+	}
+	return result
+}
+
+// index reports the element read by {{ index .services 0 }} or {{ index .labels "app.kubernetes.io/name" }}
+// instead of the collection, and returns its value.
+func (a *analyzer) index(cmd *parse.CommandNode, s *scope) (value, bool) {
+	v, ok := a.indexed(cmd, s)
+	if !ok {
+		return unknown, false
+	}
+	return a.reference(s, cmd.Args[1], v), true
+}
+
+// indexed returns the value of a call of the index function without reporting it. It only applies
+// when all indices are constants.
+func (a *analyzer) indexed(cmd *parse.CommandNode, s *scope) (value, bool) {
+	if !isFunc(cmd, "index") || len(cmd.Args) < 3 {
+		return unknown, false
+	}
+	for _, arg := range cmd.Args[2:] {
+		switch literal(arg).(type) {
+		case int, string:
+		default:
+			return unknown, false
+		}
+	}
+	v := a.resolve(cmd.Args[1], s)
+	for _, arg := range cmd.Args[2:] {
+		switch k := literal(arg).(type) {
+		case int:
+			v = v.at(k)
+		case string:
+			v = v.key(k)
+		}
+	}
+	return v, true
+}
+
+// arg reports the parameters referenced by a command argument and returns its value.
+func (a *analyzer) arg(node parse.Node, s *scope) value {
+	switch n := node.(type) {
+	case *parse.ChainNode:
+		// (.a).b references a.b only, not a on its own.
+		return a.reference(s, n, a.resolve(n.Node, s).field(n.Field...))
+	case *parse.PipeNode:
+		return a.pipe(n, s)
+	case *parse.DotNode:
+		// Dot and plain variables hold values whose references were already reported.
+		return s.dot
+	case *parse.VariableNode:
+		if len(n.Ident) == 1 {
+			return a.resolve(n, s)
+		}
+	}
+	return a.reference(s, node, a.resolve(node, s))
+}
+
+// resolve returns the value of a field, variable, dot or chain without reporting it.
+// Other arguments, such as a parenthesized pipeline, are walked and reported as usual.
+func (a *analyzer) resolve(node parse.Node, s *scope) value {
+	switch n := node.(type) {
+	case *parse.FieldNode:
+		return s.dot.field(n.Ident...)
+	case *parse.VariableNode:
+		v, ok := s.vars[n.Ident[0]]
+		if !ok {
+			return unknown
+		}
+		return v.field(n.Ident[1:]...)
+	case *parse.DotNode:
+		return s.dot
+	case *parse.ChainNode:
+		return a.resolve(n.Node, s).field(n.Field...)
+	case *parse.PipeNode:
+		if len(n.Decl) == 0 && len(n.Cmds) == 1 && len(n.Cmds[0].Args) == 1 {
+			return a.resolve(n.Cmds[0].Args[0], s)
+		}
+		if len(n.Decl) == 0 && len(n.Cmds) == 1 {
+			if v, ok := a.indexed(n.Cmds[0], s); ok {
+				return v
+			}
+		}
+		return a.pipe(n, s)
+	}
+	return unknown
+}
+
+// reference reports a known parameter path and returns the value.
+func (a *analyzer) reference(s *scope, node parse.Node, v value) value {
+	if !v.known || v.path == "" {
+		return v
+	}
+	ref := reference{path: v.path, usage: UsageRequired, within: s.within(), file: a.tree.ParseName, line: a.line(node)}
+	switch {
+	case a.condition:
+		ref.usage = UsageGuard
+	case a.optional:
+		ref.usage, ref.def = UsageOptional, a.def
+	}
+	a.report(ref)
+	return v
+}
+
+// line returns the line of a node in the template being analysed. Templates defined in a file
+// share its text, so the line is relative to the file.
+func (a *analyzer) line(node parse.Node) int {
+	location, _ := a.tree.ErrorContext(node)
+	// The location is "name:line:col".
+	parts := strings.Split(location, ":")
+	if len(parts) < 3 {
+		return 0
+	}
+	line, _ := strconv.Atoi(parts[len(parts)-2])
+	return line
+}
+
+// isDefault reports whether a command calls the default function.
+func isDefault(cmd *parse.CommandNode) bool {
+	return isFunc(cmd, "default")
+}
+
+// isFunc reports whether a command calls the named function.
+func isFunc(cmd *parse.CommandNode, name string) bool {
+	ident, ok := cmd.Args[0].(*parse.IdentifierNode)
+	return ok && ident.Ident == name
+}
+
+// literal returns the value of a constant argument such as "MIT", 8080 or true, and nil for other arguments.
+func literal(node parse.Node) interface{} {
+	switch n := node.(type) {
+	case *parse.StringNode:
+		return n.Text
+	case *parse.BoolNode:
+		return n.True
+	case *parse.NumberNode:
+		switch {
+		case n.IsInt:
+			return int(n.Int64)
+		case n.IsFloat:
+			return n.Float64
+		}
+	}
+	return nil
+}
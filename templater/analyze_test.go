@@ -0,0 +1,150 @@
+package templater
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"sort"
+	"testing"
+	"text/template"
+)
+
+func TestCollectReferences(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		expected []string
+	}{
+		{"field", "{{ .name }}", []string{"name"}},
+		{"chain", "{{ (.user).name }}", []string{"user.name"}},
+		{"function arguments", `{{ printf "%s-%s" .a .b.c | upper }}`, []string{"a", "b.c"}},
+		{"if and else", "{{ if .docker }}{{ .image }}{{ else if .podman }}{{ .pod }}{{ else }}{{ .fallback }}{{ end }}",
+			[]string{"docker", "fallback", "image", "pod", "podman"}},
+		{"with", "{{ with .project }}{{ .name }}{{ else }}{{ .other }}{{ end }}",
+			[]string{"other", "project", "project.name"}},
+		{"range", "{{ range .items }}{{ .name }}{{ $.prefix }}{{ end }}",
+			[]string{"items", "items[].name", "prefix"}},
+		{"range variables", "{{ range $i, $item := .items }}{{ $item.name }}{{ end }}",
+			[]string{"items", "items[].name"}},
+		{"nested range", "{{ range .modules }}{{ range .deps }}{{ .id }}{{ end }}{{ end }}",
+			[]string{"modules", "modules[].deps", "modules[].deps[].id"}},
+		{"variables", "{{ $p := .project }}{{ $p.name }}{{ $p = .other }}{{ $p.id }}",
+			[]string{"other", "other.id", "project", "project.name"}},
+		{"root variable", "{{ with .a }}{{ $.b.c }}{{ end }}", []string{"a", "b.c"}},
+		{"computed values", "{{ with index .m .key }}{{ .x }}{{ end }}", []string{"key", "m"}},
+		{"index", `{{ index .services 0 }}{{ (index .services 1).name }}{{ with index .m "k" }}{{ .x }}{{ end }}`,
+			[]string{"m.k", "m.k.x", "services[0]", "services[1].name"}},
+		{"index with dotted key", `{{ index .labels "app.kubernetes.io/name" }}{{ index . "a.b" 0 }}`,
+			[]string{`"a.b"[0]`, `labels."app.kubernetes.io/name"`}},
+		{"define and template", `{{ define "user" }}{{ .name }}{{ $.id }}{{ end }}{{ template "user" .owner }}`,
+			[]string{"owner", "owner.id", "owner.name"}},
+		{"template without data", `{{ define "t" }}{{ .name }}{{ end }}{{ template "t" }}`, nil},
+		{"define not invoked", `{{ define "block" }}{{ .name }}{{ end }}`, []string{"name"}},
+		{"recursive template", `{{ define "tree" }}{{ .name }}{{ range .children }}{{ template "tree" . }}{{ end }}{{ end }}{{ template "tree" .root }}`,
+			[]string{"root", "root.children", "root.name"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpl, err := template.New("test").Funcs(FuncMap()).Parse(tt.content)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			collectReferences(tmpl, func(ref reference) {
+				got = append(got, ref.path)
+			})
+			sort.Strings(got)
+			got = slices.Compact(got)
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("collectReferences(%q) = %v; want %v", tt.content, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestAnalyze(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"a.tmpl": "{{ .name }}\n{{ .license | default \"MIT\" }} {{ default 8080 .port }}\n{{ if .docker }}{{ .image }}{{ end }}",
+		"b.tmpl": `{{ range .items }}{{ .id }}{{ end }}{{ with .ci }}{{ .provider }}{{ end }}{{ if eq .db "postgres" }}pg{{ end }}{{ .name | default "x" }}{{ if .docker }}{{ else }}{{ .fallback }}{{ end }}`,
+		"c.tmpl": `{{ if .docker }}{{ .image }}{{ end }}{{ .license }}`,
+	}
+	var paths []string
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		paths = append(paths, path)
+	}
+
+	params, err := Analyze(paths)
+	if err != nil {
+		t.Fatalf("Analyze() error: %v", err)
+	}
+	locations := make(map[string][]string)
+	for i := range params {
+		for _, l := range params[i].Locations {
+			rel, _ := filepath.Rel(dir, l.File)
+			locations[params[i].Name] = append(locations[params[i].Name], fmt.Sprintf("%s:%d", rel, l.Line))
+		}
+		params[i].Locations = nil
+	}
+	if got, want := locations["image"], []string{"a.tmpl:3", "c.tmpl:1"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("locations of image = %v; want %v", got, want)
+	}
+	if got, want := locations["license"], []string{"a.tmpl:2", "c.tmpl:1"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("locations of license = %v; want %v", got, want)
+	}
+	expected := []Parameter{
+		{Name: "ci", Usage: UsageGuard},
+		{Name: "ci.provider", Usage: UsageRequired, Within: "ci"},
+		{Name: "db", Usage: UsageGuard},
+		{Name: "docker", Usage: UsageGuard},
+		{Name: "fallback", Usage: UsageRequired, Within: "docker", Unless: true},
+		{Name: "image", Usage: UsageRequired, Within: "docker"},
+		{Name: "items", Usage: UsageGuard, Ranged: true},
+		{Name: "items[].id", Usage: UsageRequired, Within: "items"},
+		{Name: "license", Usage: UsageRequired},
+		{Name: "name", Usage: UsageRequired},
+		{Name: "port", Usage: UsageOptional, Default: 8080},
+	}
+	if !reflect.DeepEqual(params, expected) {
+		t.Errorf("Analyze() = %+v; want %+v", params, expected)
+	}
+}
+
+func TestRequiredNames(t *testing.T) {
+	params := []Parameter{
+		{Name: "name", Usage: UsageRequired},
+		{Name: "license", Usage: UsageOptional, Default: "MIT"},
+		{Name: "docker", Usage: UsageGuard},
+		{Name: "image", Usage: UsageRequired, Within: "docker"},
+		{Name: "fallback", Usage: UsageRequired, Within: "docker", Unless: true},
+		{Name: "items[].id", Usage: UsageRequired, Within: "items"},
+		{Name: "project.name", Usage: UsageRequired, Within: "project"},
+	}
+	tests := []struct {
+		name     string
+		values   map[string]interface{}
+		expected []string
+	}{
+		{"guards unset", map[string]interface{}{}, []string{"name", "fallback"}},
+		{"guards false", map[string]interface{}{"docker": false, "items": []interface{}{}}, []string{"name", "fallback"}},
+		{"guards set", map[string]interface{}{
+			"docker":  true,
+			"items":   []interface{}{map[string]interface{}{"id": 1}},
+			"project": map[string]interface{}{"id": 1},
+		}, []string{"name", "image", "items[].id", "project.name"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := RequiredNames(params, tt.values); !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("RequiredNames() = %v; want %v", got, tt.expected)
+			}
+		})
+	}
+}
@@ -2,9 +2,15 @@ package templater
 
 import (
 	"bytes"
+	"cmp"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"text/template"
@@ -14,86 +20,278 @@ import (
 	"github.com/dirtydriver/projgen/utils"
 )
 
-func collectPlaceholders(node parse.Node, placeholders map[string]struct{}) {
-	switch n := node.(type) {
-	case *parse.ListNode:
-		if n == nil {
-			return
+// How a parameter is used by the templates.
+const (
+	// UsageRequired parameters are rendered and must be set.
+	UsageRequired = "required"
+	// UsageOptional parameters are only passed to the default function, which provides a value.
+	UsageOptional = "optional"
+	// UsageGuard parameters are only tested by {{ if }}, {{ with }} or {{ range }}; leaving them
+	// unset skips the block.
+	UsageGuard = "guard"
+)
+
+// Parameter describes a parameter used by templates.
+type Parameter struct {
+	// Name is the path of the parameter in dot notation, e.g. "project.name" or "items[].name".
+	Name  string
+	Usage string
+	// Default is the literal default of an optional parameter, e.g. "MIT" for {{ .license | default "MIT" }}.
+	Default interface{}
+	// Within is the guard the parameter is read under: a required parameter used only inside
+	// {{ if .docker }} is needed only when docker is set.
+	Within string
+	// Unless marks Within as the guard of an {{ else }} branch: the parameter is needed only when
+	// Within is not set.
+	Unless bool
+	// Ranged is set when a {{ range }} iterates over the parameter, which makes it a list or a map.
+	Ranged bool
+	// Locations lists where the parameter is used, ordered by file and line.
+	Locations []Location
+}
+
+// Location is a place where a template uses a parameter. Line is zero for a templated file name.
+type Location struct {
+	File string
+	Line int
+}
+
+func (l Location) String() string {
+	if l.Line == 0 {
+		return l.File
+	}
+	return fmt.Sprintf("%s:%d", l.File, l.Line)
+}
+
+// Needed reports whether a parameter must be set for the given parameter values.
+func (p *Parameter) Needed(params map[string]interface{}) bool {
+	if p.Usage != UsageRequired {
+		return false
+	}
+	if p.Within == "" {
+		return true
+	}
+	return guardSet(p.Within, params) != p.Unless
+}
+
+// guardSet reports whether the guard parameter is set, so that the blocks it guards run.
+func guardSet(guard string, params map[string]interface{}) bool {
+	if strings.Contains(guard, "[]") {
+		return len(utils.MissingKeys(params, []string{guard})) == 0
+	}
+	value, ok := utils.LookupKey(params, guard)
+	if !ok {
+		return false
+	}
+	truth, _ := template.IsTrue(value)
+	return truth
+}
+
+// RequiredNames returns the names of the parameters that must be set for the given parameter values.
+func RequiredNames(parameters []Parameter, params map[string]interface{}) []string {
+	var names []string
+	for _, p := range parameters {
+		if p.Needed(params) {
+			names = append(names, p.Name)
+		}
+	}
+	return names
+}
+
+// collectReferences reports the parameter references of a parsed template, including the
+// templates it defines. See analyzer for how paths are resolved and classified.
+func collectReferences(tmpl *template.Template, report func(reference)) {
+	newAnalyzer(tmpl, report).analyze(tmpl.Name())
+}
+
+// classify combines the references to each parameter into a Parameter, sorted by name.
+// A parameter is required if any use requires it, optional if any use gives it a default and a guard otherwise.
+// A required parameter is only within a guard when all uses requiring it are within the same guard.
+func classify(refs []reference) []Parameter {
+	byName := make(map[string]*Parameter)
+	var names []string
+	for _, ref := range refs {
+		location := Location{File: ref.file, Line: ref.line}
+		p, ok := byName[ref.path]
+		if !ok {
+			p = &Parameter{Name: ref.path, Usage: ref.usage, Default: ref.def, Within: ref.within, Ranged: ref.ranged, Locations: []Location{location}}
+			byName[ref.path] = p
+			names = append(names, ref.path)
+			continue
 		}
-		for _, child := range n.Nodes {
-			collectPlaceholders(child, placeholders)
+		if !slices.Contains(p.Locations, location) {
+			p.Locations = append(p.Locations, location)
 		}
-	case *parse.ActionNode:
-		collectPlaceholders(n.Pipe, placeholders)
-	case *parse.PipeNode:
-		for _, cmd := range n.Cmds {
-			collectPlaceholders(cmd, placeholders)
+		p.Ranged = p.Ranged || ref.ranged
+		switch {
+		case ref.usage == UsageRequired && p.Usage != UsageRequired:
+			p.Usage, p.Default, p.Within = UsageRequired, nil, ref.within
+		case ref.usage == UsageRequired && p.Within != ref.within:
+			p.Within = ""
+		case ref.usage == UsageOptional && p.Usage == UsageGuard:
+			p.Usage, p.Default = UsageOptional, ref.def
+		case ref.usage == UsageOptional && p.Usage == UsageOptional && p.Default == nil:
+			p.Default = ref.def
 		}
-	case *parse.CommandNode:
-		for _, arg := range n.Args {
-			collectPlaceholders(arg, placeholders)
+	}
+	sort.Strings(names)
+	parameters := make([]Parameter, len(names))
+	for i, name := range names {
+		parameters[i] = *byName[name]
+		if within, negated := strings.CutPrefix(parameters[i].Within, "!"); negated {
+			parameters[i].Within, parameters[i].Unless = within, true
 		}
-	case *parse.FieldNode:
-		// FieldNode represents expressions like {{ .GroupID }}
-		// The Ident slice contains the field names, which we join with dots.
-		placeholder := strings.Join(n.Ident, ".")
-		placeholders[placeholder] = struct{}{}
-	case *parse.ChainNode:
-		// ChainNode represents chained accesses like {{ .User.Name }}
-		var base string
-		if fieldNode, ok := n.Node.(*parse.FieldNode); ok {
-			base = "." + strings.Join(fieldNode.Ident, ".")
-		} else {
-			// Fallback: use the node's string representation.
-			base = fmt.Sprintf("%v", n.Node)
+		slices.SortFunc(parameters[i].Locations, func(a, b Location) int {
+			return cmp.Or(strings.Compare(a.File, b.File), cmp.Compare(a.Line, b.Line))
+		})
+	}
+	return parameters
+}
+
+// FuncMap returns the functions available to templates and templated paths:
+// all Sprig functions plus the projgen specific helpers.
+//   - packagePath converts a dotted package name into a path ("com.acme.app" -> "com/acme/app")
+func FuncMap() template.FuncMap {
+	funcs := sprig.TxtFuncMap()
+	funcs["packagePath"] = func(pkg string) string {
+		return strings.ReplaceAll(pkg, ".", "/")
+	}
+	return funcs
+}
+
+// collectPathPlaceholders harvests placeholders from the segments of a file path that contain template expressions.
+func collectPathPlaceholders(file string, report func(reference)) error {
+	for _, segment := range strings.Split(filepath.ToSlash(file), "/") {
+		if !strings.Contains(segment, "{{") {
+			continue
 		}
-		if len(n.Field) > 0 {
-			base += "." + strings.Join(n.Field, ".")
+		tmpl, err := template.New(segment).Funcs(FuncMap()).Parse(segment)
+		if err != nil {
+			parseErr := newParseError(file, err)
+			parseErr.Line, parseErr.Column = 0, 0
+			parseErr.Msg = fmt.Sprintf("in path segment %q: %s", segment, parseErr.Msg)
+			return parseErr
 		}
-		placeholders[base] = struct{}{}
+		// References in a name have no line.
+		collectReferences(tmpl, func(ref reference) {
+			ref.line = 0
+			report(ref)
+		})
 	}
+	return nil
+}
+
+// ParseError is a syntax error in a template file, located by line and, when the template parser
+// reports it, column. Line is zero for errors in a templated file name.
+type ParseError struct {
+	File   string
+	Line   int
+	Column int
+	Msg    string
+}
+
+func (e *ParseError) Error() string {
+	switch {
+	case e.Line == 0:
+		return fmt.Sprintf("%s: %s", e.File, e.Msg)
+	case e.Column == 0:
+		return fmt.Sprintf("%s:%d: %s", e.File, e.Line, e.Msg)
+	}
+	return fmt.Sprintf("%s:%d:%d: %s", e.File, e.Line, e.Column, e.Msg)
+}
+
+// templateErrorPattern matches the location text/template puts in front of its errors,
+// e.g. "template: Main.java.tmpl:3: unexpected EOF" or "template: name:3:12: ...".
+var templateErrorPattern = regexp.MustCompile(`^template: .*?:(\d+):(?:(\d+):)? (.*)$`)
+
+// newParseError locates an error of the template parser in the given file.
+func newParseError(file string, err error) *ParseError {
+	parseErr := &ParseError{File: file, Msg: err.Error()}
+	if m := templateErrorPattern.FindStringSubmatch(err.Error()); m != nil {
+		parseErr.Line, _ = strconv.Atoi(m[1])
+		parseErr.Column, _ = strconv.Atoi(m[2])
+		parseErr.Msg = m[3]
+	}
+	return parseErr
 }
 
 // CollectParameters analyzes template files and returns a list of unique parameter names used in them.
-// It processes templates concurrently for better performance.
+// Parameters used in templated file and directory names are reported as well. Nested parameters are
+// reported in dot notation, and the elements of ranged over lists with [], e.g. "items[].name".
 func CollectParameters(tempFiles []string) ([]string, error) {
+	parameters, err := Analyze(tempFiles)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, len(parameters))
+	for i, p := range parameters {
+		names[i] = p.Name
+	}
+	return names, nil
+}
+
+// Analyze analyzes template files, including their templated file and directory names, and
+// describes every parameter they use, sorted by name. Of files that are not templates (without
+// a .tmpl extension), such as plain files in a templated directory, only the names are analyzed.
+// It processes templates concurrently for better performance. Templates are parsed with FuncMap, like
+// when rendering; the ParseErrors of all files are returned together, ordered by file.
+func Analyze(tempFiles []string) ([]Parameter, error) {
+	layered := make([][]string, len(tempFiles))
+	for i, file := range tempFiles {
+		layered[i] = []string{file}
+	}
+	return AnalyzeLayered(layered)
+}
+
+// AnalyzeLayered analyzes files like Analyze, where each element lists the files of a template
+// and the templates extending it, from the base to the most specific one, as passed to RenderLayered.
+// They are parsed into one template set, so the parameters of a parent block that a child overrides
+// are not reported. Names are taken from the most specific file.
+func AnalyzeLayered(files [][]string) ([]Parameter, error) {
 
 	var (
-		wg         sync.WaitGroup
-		mu         sync.Mutex
-		parameters = make(map[string]struct{})
-		errChan    = make(chan error, len(tempFiles))
+		wg   sync.WaitGroup
+		refs = make([][]reference, len(files))
+		errs = make([]error, len(files))
 	)
 
-	for _, file := range tempFiles {
+	for i, layers := range files {
 		wg.Add(1)
-		go func(file string) {
+		go func(i int, layers []string) {
 
 			defer wg.Done()
-			tmpl, err := template.ParseFiles(file)
-			if err != nil {
-				errChan <- err
-				return
+			// Each file has its own slots, so the result does not depend on the order the goroutines finish.
+			file := layers[len(layers)-1]
+			report := func(ref reference) {
+				ref.file = file
+				refs[i] = append(refs[i], ref)
 			}
-			localParameters := make(map[string]struct{})
-			collectPlaceholders(tmpl.Root, localParameters)
-			mu.Lock()
-			for param := range localParameters {
-				parameters[param] = struct{}{}
+			if IsTemplate(file) {
+				main, err := parseLayered(layers)
+				if err != nil {
+					errs[i] = err
+					return
+				}
+				// References are located in the file they were parsed from, see parseLayered.
+				names := map[string]string{filepath.Base(layers[0]): layers[0]}
+				for _, layer := range layers[1:] {
+					names[layer] = layer
+				}
+				newAnalyzer(main, func(ref reference) {
+					ref.file = names[ref.file]
+					refs[i] = append(refs[i], ref)
+				}).analyze(main.Name())
 			}
-			mu.Unlock()
-		}(file)
+			errs[i] = collectPathPlaceholders(file, report)
+		}(i, layers)
 
 	}
 	wg.Wait()
-	close(errChan)
-	var placeholderList []string
-	for param := range parameters {
 
-		placeholderList = append(placeholderList, param)
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
 	}
-
-	return utils.RemoveDuplicates(placeholderList), nil
+	return classify(slices.Concat(refs...)), nil
 
 }
 
@@ -103,7 +301,7 @@ func CollectParameters(tempFiles []string) ([]string, error) {
 // Examples of Sprig functions include: upper, lower, title, trim, default, date, repeat, etc.
 func RenderTemplate(file string, params map[string]interface{}) (bytes.Buffer, error) {
 	// Parse the template file
-	tmpl, err := template.New(filepath.Base(file)).Funcs(sprig.TxtFuncMap()).ParseFiles(file)
+	tmpl, err := template.New(filepath.Base(file)).Funcs(FuncMap()).ParseFiles(file)
 	if err != nil {
 		return bytes.Buffer{}, err // Return the error immediately
 	}
@@ -121,6 +319,103 @@ func RenderTemplate(file string, params map[string]interface{}) (bytes.Buffer, e
 	return output, nil
 }
 
+// RenderLayered renders a template file that is overridden by templates extending it.
+// The files are ordered from the base template to the most specific one and parsed into one
+// template set, so a file consisting only of {{ define }} blocks overrides the named blocks of the
+// files before it, while a file with content of its own replaces them. The last file with content
+// is executed.
+func RenderLayered(files []string, params map[string]interface{}) (bytes.Buffer, error) {
+	if len(files) == 1 {
+		return RenderTemplate(files[0], params)
+	}
+
+	main, err := parseLayered(files)
+	if err != nil {
+		return bytes.Buffer{}, err
+	}
+	var output bytes.Buffer
+	if err := main.Execute(&output, params); err != nil {
+		return bytes.Buffer{}, err
+	}
+	return output, nil
+}
+
+// parseLayered parses the files of a template and the templates extending it into one template
+// set, as RenderLayered renders them, and returns the template that is executed. The first file is
+// named after its base name, the others after their path. Syntax errors are returned as ParseErrors.
+func parseLayered(files []string) (*template.Template, error) {
+	root := template.New(filepath.Base(files[0])).Funcs(FuncMap())
+	main := root
+	for i, file := range files {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		tmpl := root
+		if i > 0 {
+			tmpl = root.New(file)
+		}
+		if _, err := tmpl.Parse(string(content)); err != nil {
+			return nil, newParseError(file, err)
+		}
+		if tmpl.Tree != nil && !parse.IsEmptyTree(tmpl.Tree.Root) {
+			main = tmpl
+		}
+	}
+	return main, nil
+}
+
+// EvalCondition evaluates a template pipeline such as '.docker' or 'eq .db "postgres"'
+// with the given parameters and reports whether it is true in the sense of {{ if }}.
+func EvalCondition(expr string, params map[string]interface{}) (bool, error) {
+	tmpl, err := template.New("condition").Funcs(FuncMap()).Parse("{{ if " + expr + " }}true{{ end }}")
+	if err != nil {
+		return false, fmt.Errorf("parsing condition %q: %w", expr, err)
+	}
+	var output bytes.Buffer
+	if err := tmpl.Execute(&output, params); err != nil {
+		return false, fmt.Errorf("evaluating condition %q: %w", expr, err)
+	}
+	return output.String() == "true", nil
+}
+
+// RenderPath renders the template expressions contained in the segments of a relative path,
+// e.g. "src/{{ .package | packagePath }}/Main.java.tmpl". Segments without expressions are kept as is.
+// Missing parameters behave as in file contents, so an unset guard such as .docker in
+// "{{ if .docker }}docker{{ end }}" is false, but printing a missing parameter into a name is an
+// error. The rendered path must stay relative.
+// When a segment renders to an empty name (ignoring a .tmpl extension), such as
+// "{{ if .docker }}Dockerfile{{ end }}", the file is meant to be skipped and an empty path is returned.
+func RenderPath(relPath string, params map[string]interface{}) (string, error) {
+	segments := strings.Split(filepath.ToSlash(relPath), "/")
+	for i, segment := range segments {
+		if !strings.Contains(segment, "{{") {
+			continue
+		}
+		tmpl, err := template.New(segment).Funcs(FuncMap()).Parse(segment)
+		if err != nil {
+			return "", fmt.Errorf("parsing path %s: %w", relPath, err)
+		}
+		var output bytes.Buffer
+		if err := tmpl.Execute(&output, params); err != nil {
+			return "", fmt.Errorf("rendering path %s: %w", relPath, err)
+		}
+		// text/template prints missing map keys as "<no value>".
+		if strings.Contains(output.String(), "<no value>") {
+			return "", fmt.Errorf("rendering path %s: segment %q uses a missing parameter", relPath, segment)
+		}
+		if strings.TrimSpace(strings.TrimSuffix(output.String(), ".tmpl")) == "" {
+			return "", nil
+		}
+		segments[i] = output.String()
+	}
+	rendered := filepath.Clean(filepath.FromSlash(strings.Join(segments, "/")))
+	if filepath.IsAbs(rendered) || rendered == ".." || strings.HasPrefix(rendered, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("rendered path %q of %s escapes the output directory", rendered, relPath)
+	}
+	return rendered, nil
+}
+
 // IsTemplate checks if a file is a template by verifying if it has a .tmpl extension.
 func IsTemplate(path string) bool {
 	return strings.Contains(filepath.Ext(path), ".tmpl")
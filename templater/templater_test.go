@@ -2,11 +2,13 @@ package templater
 
 import (
 	"bytes"
+	"errors"
 	"io/ioutil"
 	"os"
 	"path/filepath"
 	"reflect"
 	"sort"
+	"strings"
 	"testing"
 )
 
@@ -88,15 +90,48 @@ func TestCollectParametersInvalidTemplate(t *testing.T) {
 	}
 
 	params, err := CollectParameters([]string{file})
-	// Since errors are not propagated, we expect a nil error and no placeholders.
-	if err != nil {
-		t.Errorf("Expected nil error for invalid template (due to current implementation), got %v", err)
+	var parseErr *ParseError
+	if !errors.As(err, &parseErr) {
+		t.Fatalf("Expected a ParseError for invalid template, got %v", err)
+	}
+	if parseErr.File != file || parseErr.Line != 1 || parseErr.Msg != "unclosed action" {
+		t.Errorf("Unexpected parse error %+v", parseErr)
 	}
 	if len(params) != 0 {
 		t.Errorf("Expected no parameters for invalid template, got %v", params)
 	}
 }
 
+// TestCollectParametersErrors checks that the errors of all files are reported together.
+func TestCollectParametersErrors(t *testing.T) {
+	tempDir := t.TempDir()
+	files := []string{
+		filepath.Join(tempDir, "valid.tmpl"),
+		filepath.Join(tempDir, "unknown.tmpl"),
+		filepath.Join(tempDir, "unclosed.tmpl"),
+		filepath.Join(tempDir, "{{ .name"),
+	}
+	contents := []string{"{{ .name | upper }}", "line\n{{ nosuch .name }}", "{{ if .a }}", "plain"}
+	for i, file := range files {
+		if err := os.WriteFile(file, []byte(contents[i]), 0644); err != nil {
+			t.Fatalf("Failed to write file: %v", err)
+		}
+	}
+
+	_, err := CollectParameters(files)
+	if err == nil {
+		t.Fatal("Expected an error, got nil")
+	}
+	expected := []string{
+		files[1] + `:2: function "nosuch" not defined`,
+		files[2] + ":1: unexpected EOF",
+		files[3] + `: in path segment "{{ .name": unclosed action`,
+	}
+	if got := strings.Split(err.Error(), "\n"); !reflect.DeepEqual(got, expected) {
+		t.Errorf("CollectParameters() error = %q; want %q", got, expected)
+	}
+}
+
 // TestRenderTemplate checks basic template rendering functionality.
 func TestRenderTemplate(t *testing.T) {
 	// Define the template content.
@@ -347,3 +382,218 @@ func TestWriteTemplate_Error(t *testing.T) {
 		t.Errorf("Expected error when writing to a directory, got nil")
 	}
 }
+
+// TestRenderPath verifies rendering of template expressions in path segments.
+func TestRenderPath(t *testing.T) {
+	params := map[string]interface{}{
+		"package": "com.acme.app",
+		"name":    "demo",
+	}
+	tests := []struct {
+		name        string
+		path        string
+		expected    string
+		expectError bool
+	}{
+		{"plain path", "src/main/Main.java.tmpl", filepath.FromSlash("src/main/Main.java.tmpl"), false},
+		{"package path", "src/{{ .package | packagePath }}/Main.java.tmpl", filepath.FromSlash("src/com/acme/app/Main.java.tmpl"), false},
+		{"file name", "{{ .name }}.md", "demo.md", false},
+		{"missing key", "{{ .missing }}/file", "", true},
+		{"escaping path", "{{ \"..\" }}/file", "", true},
+		{"invalid expression", "{{ .name /file", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := RenderPath(tt.path, params)
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("expected error, got %q", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("RenderPath returned error: %v", err)
+			}
+			if got != tt.expected {
+				t.Errorf("RenderPath(%q) = %q; want %q", tt.path, got, tt.expected)
+			}
+		})
+	}
+}
+
+// TestCollectParametersFromPaths verifies that parameters used in file and directory names are reported.
+func TestCollectParametersFromPaths(t *testing.T) {
+	tempDir := t.TempDir()
+	dir := filepath.Join(tempDir, "src", "{{ .package | packagePath }}")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	file := filepath.Join(dir, "{{ .name }}.java.tmpl")
+	if err := os.WriteFile(file, []byte("class {{.className}} {}"), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+
+	params, err := CollectParameters([]string{file})
+	if err != nil {
+		t.Fatalf("CollectParameters returned error: %v", err)
+	}
+	expected := []string{"className", "name", "package"}
+	sort.Strings(params)
+	if !reflect.DeepEqual(params, expected) {
+		t.Errorf("Expected parameters %v, got %v", expected, params)
+	}
+}
+
+// TestAnalyzePlainFilePaths verifies that only the names of files that are not templates are analyzed.
+func TestAnalyzePlainFilePaths(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "{{ .name }}")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	file := filepath.Join(dir, "README.md")
+	if err := os.WriteFile(file, []byte("{{ .notAParameter"), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+
+	params, err := Analyze([]string{file})
+	if err != nil {
+		t.Fatalf("Analyze returned error: %v", err)
+	}
+	if len(params) != 1 || params[0].Name != "name" || params[0].Usage != UsageRequired {
+		t.Errorf("Analyze() = %+v; want only the required parameter name", params)
+	}
+}
+
+// TestAnalyzeLayered verifies that blocks overridden by a child template are not analyzed in
+// the parent's version, and that references are located in the file they appear in.
+func TestAnalyzeLayered(t *testing.T) {
+	dir := t.TempDir()
+	base := filepath.Join(dir, "base", "README.md.tmpl")
+	child := filepath.Join(dir, "child", "README.md.tmpl")
+	for file, content := range map[string]string{
+		base:  "{{ .title }}\n{{ block \"body\" . }}{{ .a }}{{ end }}",
+		child: "{{ define \"body\" }}{{ .b }}{{ end }}",
+	} {
+		if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(file, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write file: %v", err)
+		}
+	}
+
+	params, err := AnalyzeLayered([][]string{{base, child}})
+	if err != nil {
+		t.Fatalf("AnalyzeLayered returned error: %v", err)
+	}
+	locations := make(map[string][]Location)
+	for _, p := range params {
+		locations[p.Name] = p.Locations
+	}
+	expected := map[string][]Location{
+		"title": {{File: base, Line: 1}},
+		"b":     {{File: child, Line: 1}},
+	}
+	if !reflect.DeepEqual(locations, expected) {
+		t.Errorf("AnalyzeLayered() locations = %v; want %v", locations, expected)
+	}
+}
+
+// TestEvalCondition verifies evaluation of rule conditions.
+func TestEvalCondition(t *testing.T) {
+	params := map[string]interface{}{
+		"docker": true,
+		"db":     "postgres",
+	}
+	tests := []struct {
+		expr     string
+		expected bool
+	}{
+		{".docker", true},
+		{"not .docker", false},
+		{".missing", false},
+		{`eq .db "postgres"`, true},
+		{`and .docker (eq .db "mysql")`, false},
+	}
+	for _, tt := range tests {
+		got, err := EvalCondition(tt.expr, params)
+		if err != nil {
+			t.Fatalf("EvalCondition(%q) returned error: %v", tt.expr, err)
+		}
+		if got != tt.expected {
+			t.Errorf("EvalCondition(%q) = %v; want %v", tt.expr, got, tt.expected)
+		}
+	}
+
+	if _, err := EvalCondition("eq .db", params); err == nil {
+		t.Error("expected error for invalid condition, got nil")
+	}
+}
+
+// TestRenderPathEmptySegment verifies that a segment rendered to an empty name skips the path.
+func TestRenderPathEmptySegment(t *testing.T) {
+	params := map[string]interface{}{"docker": false, "ci": true}
+	for _, p := range []string{"{{ if .docker }}Dockerfile{{ end }}", "{{ if .docker }}docker{{ end }}/compose.yml.tmpl", "{{ if .docker }}Dockerfile{{ end }}.tmpl"} {
+		got, err := RenderPath(p, params)
+		if err != nil {
+			t.Fatalf("RenderPath(%q) returned error: %v", p, err)
+		}
+		if got != "" {
+			t.Errorf("RenderPath(%q) = %q; want empty path", p, got)
+		}
+	}
+	got, err := RenderPath("{{ if .ci }}ci{{ end }}/build.yml", params)
+	if err != nil || got != filepath.FromSlash("ci/build.yml") {
+		t.Errorf("RenderPath() = %q, %v; want ci/build.yml", got, err)
+	}
+
+	// An unset guard is false, as in file contents.
+	got, err = RenderPath("{{ if .unset }}unset{{ end }}/file.txt", params)
+	if err != nil || got != "" {
+		t.Errorf("RenderPath() = %q, %v; want empty path for an unset guard", got, err)
+	}
+	got, err = RenderPath("{{ if .unset }}{{ .unset }}{{ else }}default{{ end }}/file.txt", params)
+	if err != nil || got != filepath.FromSlash("default/file.txt") {
+		t.Errorf("RenderPath() = %q, %v; want default/file.txt", got, err)
+	}
+}
+
+// TestRenderLayered verifies block overrides and full replacement by child templates.
+func TestRenderLayered(t *testing.T) {
+	tempDir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(tempDir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+		return path
+	}
+	base := write("base.tmpl", `# {{ .name }}
+{{ block "deps" . }}none{{ end }}
+{{ block "footer" . }}base footer{{ end }}`)
+	blocks := write("blocks.tmpl", `{{ define "deps" }}{{ range .deps }}- {{ . }} {{ end }}{{ end }}`)
+	replacement := write("replacement.tmpl", `replaced {{ template "deps" . }}`)
+
+	params := map[string]interface{}{"name": "demo", "deps": []string{"a", "b"}}
+	tests := []struct {
+		name     string
+		files    []string
+		expected string
+	}{
+		{"base only", []string{base}, "# demo\nnone\nbase footer"},
+		{"block override", []string{base, blocks}, "# demo\n- a - b \nbase footer"},
+		{"replacement", []string{base, blocks, replacement}, "replaced - a - b "},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output, err := RenderLayered(tt.files, params)
+			if err != nil {
+				t.Fatalf("RenderLayered returned error: %v", err)
+			}
+			if output.String() != tt.expected {
+				t.Errorf("RenderLayered() = %q; want %q", output.String(), tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,115 @@
+package utils
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// List merge modes of DeepMerge: lists of a later map replace those of an earlier one, or are
+// appended to them.
+const (
+	ListsReplace = "replace"
+	ListsAppend  = "append"
+)
+
+// ListMergeModes lists the valid list merge modes.
+var ListMergeModes = []string{ListsReplace, ListsAppend}
+
+// DeepMerge merges src into dst, the way a later parameter file overrides an earlier one: maps
+// are merged key by key, lists are replaced or appended to depending on lists, and all other
+// values of src replace those of dst. Values taken from src are copied. Keys are merged in sorted
+// order and set, when not nil, is called with the path of every value taken from src, so that
+// merging is deterministic.
+func DeepMerge(dst, src map[string]interface{}, lists string, set func(path string)) error {
+	if !slices.Contains(ListMergeModes, lists) {
+		return fmt.Errorf("unsupported list merge mode %q, expected one of %s", lists, strings.Join(ListMergeModes, ", "))
+	}
+	if set == nil {
+		set = func(string) {}
+	}
+	mergeMaps(dst, src, nil, lists == ListsAppend, set)
+	return nil
+}
+
+func mergeMaps(dst, src map[string]interface{}, prefix []PathStep, appendLists bool, set func(string)) {
+	keys := make([]string, 0, len(src))
+	for k := range src {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		path := append(slices.Clip(prefix), PathStep{Key: k})
+		if from, ok := toStringMap(src[k]); ok {
+			if into, ok := toStringMap(dst[k]); ok {
+				dst[k] = into
+				mergeMaps(into, from, path, appendLists, set)
+				continue
+			}
+		}
+		if from, ok := src[k].([]interface{}); ok && appendLists {
+			if into, ok := dst[k].([]interface{}); ok {
+				for i := range from {
+					set(FormatPath(append(slices.Clip(path), PathStep{Index: len(into) + i, IsIndex: true})))
+				}
+				dst[k] = append(slices.Clip(into), copyValue(from).([]interface{})...)
+				continue
+			}
+		}
+		dst[k] = copyValue(src[k])
+		set(FormatPath(path))
+	}
+}
+
+// FormatPath returns the path of the steps in the form read by ParsePath, e.g. "services[0].port".
+func FormatPath(steps []PathStep) string {
+	var b strings.Builder
+	for i, s := range steps {
+		switch {
+		case s.Elements:
+			b.WriteString("[]")
+		case s.IsIndex:
+			fmt.Fprintf(&b, "[%d]", s.Index)
+		default:
+			if i > 0 {
+				b.WriteByte('.')
+			}
+			b.WriteString(FormatKey(s.Key))
+		}
+	}
+	return b.String()
+}
+
+// Sources records where the values of a parameter map came from, such as the parameter file or
+// flag that set them, by path.
+type Sources map[string]string
+
+// Set records the source of the value at path, replacing the sources of the values below it.
+func (s Sources) Set(path, source string) {
+	if steps, err := ParsePath(path); err == nil {
+		path = FormatPath(steps)
+	}
+	for recorded := range s {
+		if strings.HasPrefix(recorded, path+".") || strings.HasPrefix(recorded, path+"[") {
+			delete(s, recorded)
+		}
+	}
+	s[path] = source
+}
+
+// Lookup returns the source of the value at path: the source recorded for it or for the closest
+// value it is part of, or an empty string when none was recorded.
+func (s Sources) Lookup(path string) string {
+	steps, err := ParsePath(path)
+	if err != nil {
+		return s[path]
+	}
+	for i := len(steps); i > 0; i-- {
+		if source, ok := s[FormatPath(steps[:i])]; ok {
+			return source
+		}
+	}
+	return ""
+}
@@ -0,0 +1,153 @@
+package utils
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDeepMerge(t *testing.T) {
+	layers := func() []map[string]interface{} {
+		return []map[string]interface{}{
+			{
+				"org":  "acme",
+				"tags": []interface{}{"base"},
+				"ci":   map[string]interface{}{"runner": "linux", "cache": true},
+			},
+			{
+				"tags": []interface{}{"team"},
+				"ci":   map[string]interface{}{"runner": "arm"},
+			},
+			{
+				"name": "svc",
+				"ci":   "none",
+			},
+		}
+	}
+
+	tests := []struct {
+		name     string
+		lists    string
+		layers   int
+		expected map[string]interface{}
+		sources  Sources
+	}{
+		{
+			name:   "maps merged, later files win",
+			lists:  ListsReplace,
+			layers: 2,
+			expected: map[string]interface{}{
+				"org":  "acme",
+				"tags": []interface{}{"team"},
+				"ci":   map[string]interface{}{"runner": "arm", "cache": true},
+			},
+			sources: Sources{"org": "0", "tags": "1", "ci": "0", "ci.runner": "1"},
+		},
+		{
+			name:   "lists appended",
+			lists:  ListsAppend,
+			layers: 2,
+			expected: map[string]interface{}{
+				"org":  "acme",
+				"tags": []interface{}{"base", "team"},
+				"ci":   map[string]interface{}{"runner": "arm", "cache": true},
+			},
+			sources: Sources{"org": "0", "tags": "0", "tags[1]": "1", "ci": "0", "ci.runner": "1"},
+		},
+		{
+			name:   "scalar replaces map",
+			lists:  ListsReplace,
+			layers: 3,
+			expected: map[string]interface{}{
+				"org":  "acme",
+				"name": "svc",
+				"tags": []interface{}{"team"},
+				"ci":   "none",
+			},
+			sources: Sources{"org": "0", "tags": "1", "ci": "2", "name": "2"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := map[string]interface{}{}
+			sources := Sources{}
+			for i, layer := range layers()[:tt.layers] {
+				source := string(rune('0' + i))
+				if err := DeepMerge(m, layer, tt.lists, func(path string) { sources.Set(path, source) }); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if !reflect.DeepEqual(m, tt.expected) {
+				t.Errorf("DeepMerge() = %v, want %v", m, tt.expected)
+			}
+			if !reflect.DeepEqual(sources, tt.sources) {
+				t.Errorf("sources = %v, want %v", sources, tt.sources)
+			}
+		})
+	}
+}
+
+func TestDeepMergeCopies(t *testing.T) {
+	src := map[string]interface{}{"ci": map[string]interface{}{"runner": "linux"}}
+	m := map[string]interface{}{}
+	if err := DeepMerge(m, src, ListsReplace, nil); err != nil {
+		t.Fatal(err)
+	}
+	SetKey(m, "ci.runner", "arm")
+	if v, _ := LookupKey(src, "ci.runner"); v != "linux" {
+		t.Errorf("merging shared a map with the source, src ci.runner = %v", v)
+	}
+	if err := DeepMerge(m, src, "merge", nil); err == nil {
+		t.Error("DeepMerge() with an unknown list mode expected an error")
+	}
+}
+
+func TestSources(t *testing.T) {
+	m := map[string]interface{}{
+		"services": []interface{}{map[string]interface{}{"port": 80}},
+		"deps":     []interface{}{"a", "b"},
+	}
+	sources := Sources{}
+	sources.Set("services", "a.yaml")
+	sources.Set("deps", "a.yaml")
+	overrides := []string{"services.0.port=81", "name=x", "tags+=y", "deps+=[c, d]", "old-"}
+	if err := ApplyOverrides(m, overrides, func(path string) { sources.Set(path, "--parameter") }); err != nil {
+		t.Fatal(err)
+	}
+
+	for path, expected := range map[string]string{
+		"services[0].port": "--parameter",
+		"services[0].name": "a.yaml",
+		"name":             "--parameter",
+		"tags[0]":          "--parameter",
+		"deps[1]":          "a.yaml",
+		"deps[2]":          "--parameter",
+		"deps[3]":          "--parameter",
+		"missing":          "",
+	} {
+		if got := sources.Lookup(path); got != expected {
+			t.Errorf("Lookup(%s) = %q, want %q", path, got, expected)
+		}
+	}
+
+	sources.Set("services", "b.yaml")
+	if got := sources.Lookup("services[0].port"); got != "b.yaml" {
+		t.Errorf("Lookup(services[0].port) = %q after replacing services, want b.yaml", got)
+	}
+}
+
+func TestWalkLeaves(t *testing.T) {
+	m := map[string]interface{}{
+		"b":      []interface{}{1, map[string]interface{}{"c": true}},
+		"a":      map[string]interface{}{},
+		"labels": map[string]interface{}{"app.kubernetes.io/name": "demo"},
+	}
+	var got []string
+	WalkLeaves(m, func(path string, value interface{}) {
+		got = append(got, path)
+	})
+	expected := []string{"a", "b[0]", "b[1].c", `labels."app.kubernetes.io/name"`}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("WalkLeaves() visited %v, want %v", got, expected)
+	}
+}
@@ -0,0 +1,150 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	yaml "sigs.k8s.io/yaml/goyaml.v3"
+)
+
+// ApplyOverrides applies a list of overrides to a map. Keys are paths as accepted by ParsePath,
+// using dot notation for nested keys and [i] for list elements. For example:
+//   - "name=John" sets m["name"] = "John"
+//   - "config.port=8080" sets m["config"]["port"] = 8080
+//   - "services[0].port=80" sets the port of the first element of the services list
+//   - "deps+=redis" appends "redis" to the deps list, "deps+=[a, b]" appends both values
+//   - "config.debug-" removes m["config"]["debug"]
+//   - `labels."app.kubernetes.io/name"=web` sets a key containing dots
+//
+// Values are read like YAML values (see ParseValue). If intermediate nested maps or lists don't
+// exist, they will be created automatically; an index may append one element to a list.
+// Overrides that are none of the above are ignored; invalid paths are reported together.
+// set, when not nil, is called with the path of every value set, as for DeepMerge: appending to a
+// list reports the indices of the new elements, and numeric keys of list elements are reported as indices.
+func ApplyOverrides(m map[string]interface{}, overrides []string, set func(path string)) error {
+	return applyOverrides(m, overrides, ParseValue, set)
+}
+
+// ApplyStringOverrides applies overrides like ApplyOverrides, but always stores the values as strings.
+func ApplyStringOverrides(m map[string]interface{}, overrides []string, set func(path string)) error {
+	return applyOverrides(m, overrides, func(raw string) interface{} { return raw }, set)
+}
+
+func applyOverrides(m map[string]interface{}, overrides []string, parse func(string) interface{}, set func(string)) error {
+	if set == nil {
+		set = func(string) {}
+	}
+	var errs []error
+	for _, override := range overrides {
+		if err := applyOverride(m, override, parse, set); err != nil {
+			errs = append(errs, fmt.Errorf("parameter %q: %w", override, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func applyOverride(m map[string]interface{}, override string, parse func(string) interface{}, set func(string)) error {
+	key, raw, found := cutAssignment(override)
+	if !found {
+		key, remove := strings.CutSuffix(override, "-")
+		if !remove || key == "" {
+			return nil
+		}
+		steps, err := ParsePath(key)
+		if err != nil {
+			return err
+		}
+		_, err = deletePath(m, steps)
+		return err
+	}
+
+	key, appendValues := strings.CutSuffix(key, "+")
+	steps, err := ParsePath(key)
+	if err != nil {
+		return err
+	}
+	value := parse(raw)
+	// appendedFrom is the index of the first element appended to an existing list, -1 otherwise.
+	appendedFrom := -1
+	if appendValues {
+		existing, _ := lookupPath(m, steps)
+		list, ok := existing.([]interface{})
+		if !ok && existing != nil {
+			return fmt.Errorf("cannot append to %s, not a list", key)
+		}
+		if ok {
+			appendedFrom = len(list)
+		}
+		if values, ok := value.([]interface{}); ok {
+			list = append(list, values...)
+		} else {
+			list = append(list, value)
+		}
+		value = list
+	}
+	if _, err := setPath(m, steps, value); err != nil {
+		return err
+	}
+
+	var current interface{} = m
+	for i, step := range steps {
+		steps[i] = step.on(current)
+		current, _ = lookupPath(current, steps[i:i+1])
+	}
+	if appendedFrom < 0 {
+		set(FormatPath(steps))
+		return nil
+	}
+	for i := appendedFrom; i < len(value.([]interface{})); i++ {
+		set(FormatPath(append(slices.Clip(steps), PathStep{Index: i, IsIndex: true})))
+	}
+	return nil
+}
+
+// cutAssignment splits an override at the first = that is not part of a quoted key.
+func cutAssignment(override string) (key, raw string, found bool) {
+	for i := 0; i < len(override); i++ {
+		switch override[i] {
+		case '"':
+			if quoted, err := strconv.QuotedPrefix(override[i:]); err == nil {
+				i += len(quoted) - 1
+			}
+		case '=':
+			return override[:i], override[i+1:], true
+		}
+	}
+	return override, "", false
+}
+
+// ParseValue converts a parameter value given on the command line the way YAML reads it:
+// integers, floats, booleans and null become typed values, quoted strings are unquoted and flow
+// collections such as [a, b] or {k: v} become lists and maps. Only canonical forms are converted,
+// so "1.10", "0755", "yes" and "" stay strings, and values that are not valid YAML are kept as is.
+func ParseValue(raw string) interface{} {
+	switch raw {
+	case "true":
+		return true
+	case "false":
+		return false
+	case "null", "~":
+		return nil
+	}
+	if i, err := strconv.Atoi(raw); err == nil && strconv.Itoa(i) == raw {
+		return i
+	}
+	if f, err := strconv.ParseFloat(raw, 64); err == nil && strconv.FormatFloat(f, 'f', -1, 64) == raw {
+		return f
+	}
+	if raw == "" || !strings.ContainsAny(raw[:1], `"'[{`) {
+		return raw
+	}
+	// YAML 1.2 keeps words such as yes, no or y as strings inside collections too.
+	var value interface{}
+	if err := yaml.Unmarshal([]byte(raw), &value); err != nil {
+		return raw
+	}
+	return value
+}
@@ -0,0 +1,287 @@
+package utils
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// PathStep is one step of a parameter path: a map key, a list index such as [0], or all elements
+// of a list or map, written [].
+type PathStep struct {
+	Key      string
+	Index    int
+	IsIndex  bool
+	Elements bool
+}
+
+// ParsePath parses a parameter path into its steps. Paths are dot separated keys, each followed by
+// any number of list indices [0] or [] for all elements, as in "services[0].ports[]". Keys that
+// contain dots or brackets are quoted, as in `labels."app.kubernetes.io/name"` or
+// `labels["app.kubernetes.io/name"]`, and a numeric key addresses a list element when the value
+// it is applied to is a list, so "services.0.name" is the same as "services[0].name".
+func ParsePath(path string) ([]PathStep, error) {
+	segments, err := splitPath(path)
+	if err != nil {
+		return nil, err
+	}
+	return parseSegments(segments)
+}
+
+// FormatKey returns a map key as a path segment, quoting it when it is not a plain key.
+func FormatKey(key string) string {
+	if key == "" || strings.ContainsAny(key, `.[]"' `) {
+		return strconv.Quote(key)
+	}
+	return key
+}
+
+// splitPath splits a path into its dot separated segments, leaving dots inside quoted keys alone.
+func splitPath(path string) ([]string, error) {
+	var segments []string
+	start := 0
+	for i := 0; i < len(path); i++ {
+		switch path[i] {
+		case '"':
+			quoted, err := strconv.QuotedPrefix(path[i:])
+			if err != nil {
+				return nil, fmt.Errorf("invalid path %q: unterminated quoted key", path)
+			}
+			i += len(quoted) - 1
+		case '.':
+			segments = append(segments, path[start:i])
+			start = i + 1
+		}
+	}
+	return append(segments, path[start:]), nil
+}
+
+// parseSegment parses a dot separated segment of a path, a plain or quoted key followed by any
+// number of indices, quoted keys in brackets or [], e.g. "services[0]", "items[]" or `"a.b"[0]`.
+func parseSegment(segment string) ([]PathStep, error) {
+	key, rest, err := cutKey(segment)
+	if err != nil {
+		return nil, err
+	}
+	steps := []PathStep{{Key: key}}
+	for rest != "" {
+		if rest[0] != '[' {
+			return nil, fmt.Errorf("invalid path segment %q: unexpected %q", segment, rest)
+		}
+		if strings.HasPrefix(rest, `["`) {
+			quoted, err := strconv.QuotedPrefix(rest[1:])
+			if err != nil {
+				return nil, fmt.Errorf("invalid path segment %q: unterminated quoted key", segment)
+			}
+			key, _ := strconv.Unquote(quoted)
+			if !strings.HasPrefix(rest[1+len(quoted):], "]") {
+				return nil, fmt.Errorf("invalid path segment %q: missing ]", segment)
+			}
+			steps = append(steps, PathStep{Key: key})
+			rest = rest[2+len(quoted):]
+			continue
+		}
+		inner, after, found := strings.Cut(rest[1:], "]")
+		if !found {
+			return nil, fmt.Errorf("invalid path segment %q: missing ]", segment)
+		}
+		rest = after
+		if inner == "" {
+			steps = append(steps, PathStep{Elements: true})
+			continue
+		}
+		index, err := strconv.Atoi(inner)
+		if err != nil || index < 0 {
+			return nil, fmt.Errorf("invalid path segment %q: index %q is not a non-negative integer", segment, inner)
+		}
+		steps = append(steps, PathStep{Index: index, IsIndex: true})
+	}
+	return steps, nil
+}
+
+// cutKey splits the key off the start of a segment and returns the key and the brackets following it.
+func cutKey(segment string) (key, rest string, err error) {
+	if strings.HasPrefix(segment, `"`) {
+		quoted, err := strconv.QuotedPrefix(segment)
+		if err != nil {
+			return "", "", fmt.Errorf("invalid path segment %q: unterminated quoted key", segment)
+		}
+		key, _ = strconv.Unquote(quoted)
+		return key, segment[len(quoted):], nil
+	}
+	key, rest, brackets := strings.Cut(segment, "[")
+	if strings.ContainsAny(key, `"]`) {
+		return "", "", fmt.Errorf("invalid path segment %q: unexpected quote or ] in key", segment)
+	}
+	if brackets {
+		rest = "[" + rest
+	}
+	return key, rest, nil
+}
+
+// parseSegments parses the segments of a path.
+func parseSegments(segments []string) ([]PathStep, error) {
+	var steps []PathStep
+	for _, segment := range segments {
+		parsed, err := parseSegment(segment)
+		if err != nil {
+			return nil, err
+		}
+		steps = append(steps, parsed...)
+	}
+	return steps, nil
+}
+
+// on returns the step as applied to value: a numeric key addresses an element of a list.
+func (s PathStep) on(value interface{}) PathStep {
+	if s.IsIndex || s.Elements {
+		return s
+	}
+	if _, ok := value.([]interface{}); !ok {
+		return s
+	}
+	if index, err := strconv.Atoi(s.Key); err == nil && index >= 0 && strconv.Itoa(index) == s.Key {
+		return PathStep{Index: index, IsIndex: true}
+	}
+	return s
+}
+
+// hasPath reports whether the value has the given steps. A [] step requires every element of a
+// list or map to have the rest of the path; empty lists and maps have no elements to check.
+func hasPath(value interface{}, steps []PathStep) bool {
+	if len(steps) == 0 {
+		return true
+	}
+	s, rest := steps[0].on(value), steps[1:]
+	switch {
+	case s.Elements:
+		var elements []interface{}
+		if list, ok := value.([]interface{}); ok {
+			elements = list
+		} else if m, ok := toStringMap(value); ok {
+			for _, e := range m {
+				elements = append(elements, e)
+			}
+		} else {
+			return false
+		}
+		for _, e := range elements {
+			if !hasPath(e, rest) {
+				return false
+			}
+		}
+		return true
+	case s.IsIndex:
+		list, ok := value.([]interface{})
+		return ok && s.Index < len(list) && hasPath(list[s.Index], rest)
+	}
+	m, ok := toStringMap(value)
+	if !ok {
+		return false
+	}
+	next, exists := m[s.Key]
+	return exists && hasPath(next, rest)
+}
+
+// setPath stores value under the steps below current and returns the updated current value.
+// Missing maps and lists are created; values that are in the way of a key are replaced by a map.
+// An index may address an existing element or append one to the end of the list.
+func setPath(current interface{}, steps []PathStep, value interface{}) (interface{}, error) {
+	if len(steps) == 0 {
+		return value, nil
+	}
+	s, rest := steps[0].on(current), steps[1:]
+	switch {
+	case s.Elements:
+		return nil, fmt.Errorf("cannot set all elements []")
+	case s.IsIndex:
+		list, ok := current.([]interface{})
+		if !ok && current != nil {
+			return nil, fmt.Errorf("cannot index %T with [%d], not a list", current, s.Index)
+		}
+		if s.Index > len(list) {
+			return nil, fmt.Errorf("index [%d] out of range, the list has %d elements", s.Index, len(list))
+		}
+		if s.Index == len(list) {
+			list = append(list, nil)
+		}
+		child, err := setPath(list[s.Index], rest, value)
+		if err != nil {
+			return nil, err
+		}
+		list[s.Index] = child
+		return list, nil
+	}
+	m, ok := toStringMap(current)
+	if !ok {
+		m = make(map[string]interface{})
+	}
+	child, err := setPath(m[s.Key], rest, value)
+	if err != nil {
+		return nil, err
+	}
+	m[s.Key] = child
+	return m, nil
+}
+
+// lookupPath returns the value under the steps below value and reports whether it exists.
+func lookupPath(value interface{}, steps []PathStep) (interface{}, bool) {
+	for _, s := range steps {
+		switch s = s.on(value); {
+		case s.Elements:
+			return nil, false
+		case s.IsIndex:
+			list, ok := value.([]interface{})
+			if !ok || s.Index >= len(list) {
+				return nil, false
+			}
+			value = list[s.Index]
+		default:
+			m, ok := toStringMap(value)
+			if !ok {
+				return nil, false
+			}
+			if value, ok = m[s.Key]; !ok {
+				return nil, false
+			}
+		}
+	}
+	return value, true
+}
+
+// deletePath removes the map entry or list element the steps address from m and reports whether
+// it existed.
+func deletePath(m map[string]interface{}, steps []PathStep) (bool, error) {
+	parentSteps, last := steps[:len(steps)-1], steps[len(steps)-1]
+	parent, ok := lookupPath(m, parentSteps)
+	if !ok {
+		return false, nil
+	}
+	last = last.on(parent)
+	var updated interface{}
+	switch {
+	case last.Elements:
+		return false, fmt.Errorf("cannot delete all elements []")
+	case last.IsIndex:
+		list, ok := parent.([]interface{})
+		if !ok || last.Index >= len(list) {
+			return false, nil
+		}
+		updated = append(list[:last.Index:last.Index], list[last.Index+1:]...)
+	default:
+		pm, ok := toStringMap(parent)
+		if !ok {
+			return false, nil
+		}
+		if _, exists := pm[last.Key]; !exists {
+			return false, nil
+		}
+		delete(pm, last.Key)
+		updated = pm
+	}
+	if len(parentSteps) == 0 {
+		return true, nil
+	}
+	_, err := setPath(m, parentSteps, updated)
+	return true, err
+}
@@ -0,0 +1,106 @@
+package utils
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParsePath(t *testing.T) {
+	tests := []struct {
+		name     string
+		path     string
+		expected []PathStep
+	}{
+		{"keys", "project.name", []PathStep{{Key: "project"}, {Key: "name"}}},
+		{"index", "services[0].port", []PathStep{{Key: "services"}, {Index: 0, IsIndex: true}, {Key: "port"}}},
+		{"elements", "items[][1]", []PathStep{{Key: "items"}, {Elements: true}, {Index: 1, IsIndex: true}}},
+		{"quoted key", `labels."app.kubernetes.io/name"`, []PathStep{{Key: "labels"}, {Key: "app.kubernetes.io/name"}}},
+		{"quoted key in brackets", `labels["a.b"][2]`, []PathStep{{Key: "labels"}, {Key: "a.b"}, {Index: 2, IsIndex: true}}},
+		{"quoted key with index", `"a.b"[0].c`, []PathStep{{Key: "a.b"}, {Index: 0, IsIndex: true}, {Key: "c"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParsePath(tt.path)
+			if err != nil {
+				t.Fatalf("ParsePath(%q) error: %v", tt.path, err)
+			}
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("ParsePath(%q) = %+v, want %+v", tt.path, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestParsePathErrors(t *testing.T) {
+	for _, path := range []string{"a[0", "a[x]", "a[-1]", "a[0]b", `a."b`, `a["b"`, `a"b`} {
+		if _, err := ParsePath(path); err == nil {
+			t.Errorf("ParsePath(%q) expected an error", path)
+		}
+	}
+}
+
+func TestFormatKey(t *testing.T) {
+	for key, expected := range map[string]string{
+		"name":                   "name",
+		"app.kubernetes.io/name": `"app.kubernetes.io/name"`,
+		"a[0]":                   `"a[0]"`,
+		"":                       `""`,
+	} {
+		if got := FormatKey(key); got != expected {
+			t.Errorf("FormatKey(%q) = %s, want %s", key, got, expected)
+		}
+		steps, err := ParsePath("m." + FormatKey(key))
+		if err != nil || len(steps) != 2 || steps[1].Key != key {
+			t.Errorf("ParsePath(m.%s) = %+v, %v; want key %q", FormatKey(key), steps, err, key)
+		}
+	}
+}
+
+func TestPathsInLists(t *testing.T) {
+	m := map[string]interface{}{
+		"services": []interface{}{
+			map[string]interface{}{"name": "web"},
+		},
+		"labels": map[string]interface{}{
+			"app.kubernetes.io/name": "demo",
+		},
+	}
+
+	for _, key := range []string{"services[0].name", "services.0.name", `labels."app.kubernetes.io/name"`, `labels["app.kubernetes.io/name"]`} {
+		if _, ok := LookupKey(m, key); !ok {
+			t.Errorf("LookupKey(%s) found nothing", key)
+		}
+	}
+	missing := MissingKeys(m, []string{"services[0].name", "services[1].name", "services.0.port", `labels."app.kubernetes.io/name"`, "labels.app"})
+	if !reflect.DeepEqual(missing, []string{"services[1].name", "services.0.port", "labels.app"}) {
+		t.Errorf("MissingKeys() = %v", missing)
+	}
+
+	SetKey(m, "services.0.port", 80)
+	SetKey(m, "services[1].name", "db")
+	SetKey(m, `labels."app.kubernetes.io/part-of"`, "shop")
+	expected := map[string]interface{}{
+		"services": []interface{}{
+			map[string]interface{}{"name": "web", "port": 80},
+			map[string]interface{}{"name": "db"},
+		},
+		"labels": map[string]interface{}{
+			"app.kubernetes.io/name":    "demo",
+			"app.kubernetes.io/part-of": "shop",
+		},
+	}
+	if !reflect.DeepEqual(m, expected) {
+		t.Errorf("after SetKey got %v, want %v", m, expected)
+	}
+
+	if !DeleteKey(m, "services.0") || !DeleteKey(m, `labels["app.kubernetes.io/name"]`) {
+		t.Fatal("DeleteKey() = false; want true")
+	}
+	if v, _ := LookupKey(m, "services[0].name"); v != "db" {
+		t.Errorf("services[0].name = %v after deleting the first service, want db", v)
+	}
+	if _, ok := LookupKey(m, `labels."app.kubernetes.io/name"`); ok {
+		t.Error("quoted key still present after DeleteKey")
+	}
+}
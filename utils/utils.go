@@ -2,6 +2,9 @@ package utils
 
 import (
 	"errors"
+	"path"
+	"slices"
+	"sort"
 	"strings"
 )
 
@@ -24,85 +27,208 @@ func RemoveDuplicates(list []string) []string {
 // It supports both simple key names and nested YAML paths using dot notation (e.g., 'project.name').
 // It returns an error listing any missing keys, or nil if all keys are present.
 func CheckMissingKeys(m map[string]interface{}, list []string) error {
+	missing := MissingKeys(m, list)
+	if len(missing) > 0 {
+		return errors.New("missing keys: " + strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// MissingKeys returns the keys from the list that do not exist in the given map, in list order.
+// Keys are paths as accepted by ParsePath; keys that are not valid paths are always missing.
+func MissingKeys(m map[string]interface{}, list []string) []string {
 	var missing []string
 	for _, key := range list {
-		if !hasNestedKey(m, strings.Split(key, ".")) {
+		segments, err := splitPath(key)
+		if err != nil || !hasNestedKey(m, segments) {
 			missing = append(missing, key)
 		}
 	}
-	if len(missing) > 0 {
-		return errors.New("missing keys: " + strings.Join(missing, ", "))
-	}
-	return nil
+	return missing
 }
 
 // hasNestedKey checks if a nested key exists in the map using path segments.
 // For example, for path ["project", "name"] it checks m["project"]["name"].
+// Segments may end in list indices, as in ["services[0]", "port"], or in [] for the elements of a
+// list or map, as in ["items[]", "name"] for the items ranged over by a template: every element
+// must have the rest of the path. A quoted segment such as `"app.kubernetes.io/name"` is a key
+// containing dots.
 func hasNestedKey(m map[string]interface{}, path []string) bool {
 	if len(path) == 0 {
 		return false
 	}
+	steps, err := parseSegments(path)
+	if err != nil {
+		return false
+	}
+	return hasPath(m, steps)
+}
+
+// LookupKey returns the value stored under a path such as 'project.name' or 'services[0].port'
+// and reports whether it was found.
+func LookupKey(m map[string]interface{}, key string) (interface{}, bool) {
+	steps, err := ParsePath(key)
+	if err != nil {
+		return nil, false
+	}
+	return lookupPath(m, steps)
+}
 
-	val, exists := m[path[0]]
-	if !exists {
+// SetKey stores value under a path, creating intermediate maps and lists as needed.
+// Nothing is stored when key is not a valid path or indexes past the end of a list;
+// ApplyOverrides reports those errors.
+func SetKey(m map[string]interface{}, key string, value interface{}) {
+	steps, err := ParsePath(key)
+	if err != nil {
+		return
+	}
+	setPath(m, steps, value)
+}
+
+// DeleteKey removes the value stored under a path and reports whether it existed.
+func DeleteKey(m map[string]interface{}, key string) bool {
+	steps, err := ParsePath(key)
+	if err != nil {
 		return false
 	}
+	deleted, err := deletePath(m, steps)
+	return deleted && err == nil
+}
 
-	if len(path) == 1 {
-		return true
+// CopyMap returns a deep copy of m. Nested maps and lists are copied, other values are shared.
+func CopyMap(m map[string]interface{}) map[string]interface{} {
+	copied := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		copied[k] = copyValue(v)
 	}
+	return copied
+}
 
-	// For nested keys, check if the value is a map and recurse
-	if nextMap, ok := val.(map[string]interface{}); ok {
-		return hasNestedKey(nextMap, path[1:])
+func copyValue(v interface{}) interface{} {
+	if nested, ok := toStringMap(v); ok {
+		return CopyMap(nested)
+	}
+	if list, ok := v.([]interface{}); ok {
+		copied := make([]interface{}, len(list))
+		for i, item := range list {
+			copied[i] = copyValue(item)
+		}
+		return copied
 	}
+	return v
+}
+
+// WalkLeaves calls visit with the path and value of every leaf of m: the values that are neither
+// maps nor lists, and empty maps and lists. Map keys are visited in sorted order, list elements in order.
+func WalkLeaves(m map[string]interface{}, visit func(path string, value interface{})) {
+	walkLeaves(m, nil, visit)
+}
 
-	// Handle yaml.Node type which might come from yaml parsing
-	if nextMap, ok := val.(map[interface{}]interface{}); ok {
-		// Convert to map[string]interface{} for consistent handling
+func walkLeaves(value interface{}, path []PathStep, visit func(string, interface{})) {
+	if m, ok := toStringMap(value); ok && len(m) > 0 {
+		keys := make([]string, 0, len(m))
+		for k := range m {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			walkLeaves(m[k], append(slices.Clip(path), PathStep{Key: k}), visit)
+		}
+		return
+	}
+	if list, ok := value.([]interface{}); ok && len(list) > 0 {
+		for i, item := range list {
+			walkLeaves(item, append(slices.Clip(path), PathStep{Index: i, IsIndex: true}), visit)
+		}
+		return
+	}
+	visit(FormatPath(path), value)
+}
+
+// toStringMap returns val as a map[string]interface{} when it is one of the map
+// types produced by the YAML decoders.
+func toStringMap(val interface{}) (map[string]interface{}, bool) {
+	switch v := val.(type) {
+	case map[string]interface{}:
+		return v, true
+	case map[interface{}]interface{}:
 		strMap := make(map[string]interface{})
-		for k, v := range nextMap {
+		for k, item := range v {
 			if ks, ok := k.(string); ok {
-				strMap[ks] = v
+				strMap[ks] = item
 			}
 		}
-		return hasNestedKey(strMap, path[1:])
+		return strMap, true
 	}
-
-	return false
+	return nil, false
 }
 
-func setNestedValues(m map[string]interface{}, path []string, value interface{}) {
-	for i := 0; i < len(path)-1; i++ {
-		k := path[i]
+// MatchGlob reports whether a slash separated relative path matches a glob pattern.
+// Segments are matched with path.Match; a "**" segment matches any number of path segments,
+// so "ci/**" matches everything below ci and "**/*.md" matches markdown files at any depth.
+func MatchGlob(pattern, name string) bool {
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(name, "/"))
+}
 
-		if _, exists := m[k]; !exists {
-			m[k] = make(map[string]interface{})
+func matchSegments(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(name); i++ {
+				if matchSegments(pattern[1:], name[i:]) {
+					return true
+				}
+			}
+			return false
 		}
-
-		if submap, exists := m[k].(map[string]interface{}); exists {
-			m = submap
-		} else {
-			newMap := make(map[string]interface{})
-			m[k] = newMap
-			m = newMap
+		if len(name) == 0 {
+			return false
 		}
+		if ok, err := path.Match(pattern[0], name[0]); err != nil || !ok {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
 	}
-	m[path[len(path)-1]] = value
+	return len(name) == 0
 }
 
-// ApplyOverrides applies a list of key-value overrides to a map using dot notation for nested keys.
-// Each override should be in the format "key.subkey=value". For example:
-//   - "name=John" sets m["name"] = "John"
-//   - "config.port=8080" sets m["config"]["port"] = "8080"
-//
-// If intermediate nested maps don't exist, they will be created automatically.
-func ApplyOverrides(m map[string]interface{}, overrides []string) {
-	for _, value := range overrides {
+// ClosestMatch returns the candidate most similar to word by edit distance, or an empty string if
+// no candidate is close enough to be a plausible typo.
+func ClosestMatch(word string, candidates []string) string {
+	best, bestDistance := "", -1
+	for _, c := range candidates {
+		d := levenshtein(strings.ToLower(word), strings.ToLower(c))
+		if bestDistance < 0 || d < bestDistance {
+			best, bestDistance = c, d
+		}
+	}
+	threshold := len(word) / 3
+	if threshold < 2 {
+		threshold = 2
+	}
+	if bestDistance < 0 || bestDistance > threshold {
+		return ""
+	}
+	return best
+}
 
-		if kv := strings.SplitN(value, "=", 2); len(kv) == 2 {
-			keyPath := strings.Split(kv[0], ".")
-			setNestedValues(m, keyPath, kv[1])
+// levenshtein computes the edit distance between two strings.
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
 		}
+		prev, curr = curr, prev
 	}
+	return prev[len(rb)]
 }
@@ -2,6 +2,7 @@ package utils
 
 import (
 	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -213,6 +214,72 @@ func TestHasNestedKey(t *testing.T) {
 			path:     []string{"parent", "child"},
 			expected: false,
 		},
+		{
+			name: "list elements have key",
+			m: map[string]interface{}{
+				"items": []interface{}{
+					map[string]interface{}{"name": "a"},
+					map[string]interface{}{"name": "b"},
+				},
+			},
+			path:     []string{"items[]", "name"},
+			expected: true,
+		},
+		{
+			name: "list element misses key",
+			m: map[string]interface{}{
+				"items": []interface{}{
+					map[string]interface{}{"name": "a"},
+					map[string]interface{}{"id": "b"},
+				},
+			},
+			path:     []string{"items[]", "name"},
+			expected: false,
+		},
+		{
+			name: "empty list",
+			m: map[string]interface{}{
+				"items": []interface{}{},
+			},
+			path:     []string{"items[]", "name"},
+			expected: true,
+		},
+		{
+			name: "map elements have key",
+			m: map[string]interface{}{
+				"services": map[string]interface{}{
+					"web": map[string]interface{}{"port": 80},
+				},
+			},
+			path:     []string{"services[]", "port"},
+			expected: true,
+		},
+		{
+			name: "elements of a scalar",
+			m: map[string]interface{}{
+				"items": "a",
+			},
+			path:     []string{"items[]", "name"},
+			expected: false,
+		},
+		{
+			name: "list index",
+			m: map[string]interface{}{
+				"services": []interface{}{
+					map[string]interface{}{"port": 80},
+				},
+			},
+			path:     []string{"services[0]", "port"},
+			expected: true,
+		},
+		{
+			name: "list index out of range",
+			m: map[string]interface{}{
+				"services": []interface{}{},
+			},
+			path:     []string{"services[0]", "port"},
+			expected: false,
+		},
 		{
 			name: "empty path",
 			m:        map[string]interface{}{},
@@ -255,7 +322,7 @@ func TestApplyOverrides(t *testing.T) {
 			expected: map[string]interface{}{
 				"existing": "value",
 				"config": map[string]interface{}{
-					"port": "8080",
+					"port": 8080,
 					"host": "localhost",
 				},
 			},
@@ -284,9 +351,9 @@ func TestApplyOverrides(t *testing.T) {
 						"c": map[string]interface{}{
 							"d": "value",
 						},
-						"x": "1",
+						"x": 1,
 					},
-					"y": "2",
+					"y": 2,
 				},
 			},
 		},
@@ -300,14 +367,199 @@ func TestApplyOverrides(t *testing.T) {
 				"":     "value",
 			},
 		},
+		{
+			name:    "typed values",
+			initial: map[string]interface{}{},
+			overrides: []string{
+				"replicas=3", "debug=true", "ratio=0.5", "version=1.10", "mode=0755", "answer=yes",
+				"deps=[a, b]", "labels={team: core, tier: 1}", `quoted="3"`, "nothing=null",
+			},
+			expected: map[string]interface{}{
+				"replicas": 3,
+				"debug":    true,
+				"ratio":    0.5,
+				"version":  "1.10",
+				"mode":     "0755",
+				"answer":   "yes",
+				"deps":     []interface{}{"a", "b"},
+				"labels":   map[string]interface{}{"team": "core", "tier": 1},
+				"quoted":   "3",
+				"nothing":  nil,
+			},
+		},
+		{
+			name: "append, index and delete",
+			initial: map[string]interface{}{
+				"deps": []interface{}{"a"},
+				"services": []interface{}{
+					map[string]interface{}{"name": "web", "port": 8080},
+				},
+				"config": map[string]interface{}{"debug": true, "level": "info"},
+			},
+			overrides: []string{
+				"deps+=redis", "deps+=[x, y]", "new+=first",
+				"services[0].port=80", "services[1].name=db",
+				"config.debug-", "missing-",
+			},
+			expected: map[string]interface{}{
+				"deps": []interface{}{"a", "redis", "x", "y"},
+				"new":  []interface{}{"first"},
+				"services": []interface{}{
+					map[string]interface{}{"name": "web", "port": 80},
+					map[string]interface{}{"name": "db"},
+				},
+				"config": map[string]interface{}{"level": "info"},
+			},
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			ApplyOverrides(tt.initial, tt.overrides)
+			if err := ApplyOverrides(tt.initial, tt.overrides, nil); err != nil {
+				t.Fatalf("ApplyOverrides() error: %v", err)
+			}
 			if !reflect.DeepEqual(tt.initial, tt.expected) {
 				t.Errorf("ApplyOverrides() = %v; want %v", tt.initial, tt.expected)
 			}
 		})
 	}
 }
+
+func TestApplyOverridesQuotedKeys(t *testing.T) {
+	m := map[string]interface{}{}
+	if err := ApplyOverrides(m, []string{`labels."app.kubernetes.io/name"=web`, `annotations."a=b"=c`}, nil); err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]interface{}{
+		"labels":      map[string]interface{}{"app.kubernetes.io/name": "web"},
+		"annotations": map[string]interface{}{"a=b": "c"},
+	}
+	if !reflect.DeepEqual(m, expected) {
+		t.Errorf("ApplyOverrides() = %v, want %v", m, expected)
+	}
+}
+
+func TestApplyOverridesErrors(t *testing.T) {
+	m := map[string]interface{}{
+		"name": "app",
+		"list": []interface{}{"a"},
+	}
+	err := ApplyOverrides(m, []string{"name+=x", "list[5]=b", "name[0]=c", "bad[x]=1", "ok=1"}, nil)
+	if err == nil {
+		t.Fatal("expected an error, got nil")
+	}
+	if got := len(strings.Split(err.Error(), "\n")); got != 4 {
+		t.Errorf("expected 4 errors, got %d: %v", got, err)
+	}
+	if m["ok"] != 1 {
+		t.Errorf("valid override not applied: %v", m)
+	}
+}
+
+func TestApplyStringOverrides(t *testing.T) {
+	m := map[string]interface{}{}
+	if err := ApplyStringOverrides(m, []string{"replicas=3", "debug=true", "tags+=1"}, nil); err != nil {
+		t.Fatalf("ApplyStringOverrides() error: %v", err)
+	}
+	expected := map[string]interface{}{"replicas": "3", "debug": "true", "tags": []interface{}{"1"}}
+	if !reflect.DeepEqual(m, expected) {
+		t.Errorf("ApplyStringOverrides() = %v; want %v", m, expected)
+	}
+}
+
+func TestLookupAndSetKey(t *testing.T) {
+	m := map[string]interface{}{
+		"project": map[interface{}]interface{}{
+			"name": "demo",
+		},
+	}
+	if v, ok := LookupKey(m, "project.name"); !ok || v != "demo" {
+		t.Errorf("LookupKey(project.name) = %v, %v; want demo, true", v, ok)
+	}
+	if _, ok := LookupKey(m, "project.version"); ok {
+		t.Error("LookupKey(project.version) found a missing key")
+	}
+
+	SetKey(m, "config.port", 8080)
+	if v, ok := LookupKey(m, "config.port"); !ok || v != 8080 {
+		t.Errorf("LookupKey(config.port) = %v, %v; want 8080, true", v, ok)
+	}
+}
+
+func TestMatchGlob(t *testing.T) {
+	tests := []struct {
+		pattern  string
+		name     string
+		expected bool
+	}{
+		{"Dockerfile", "Dockerfile", true},
+		{"Dockerfile", "sub/Dockerfile", false},
+		{"*.md", "README.md", true},
+		{"ci/**", "ci/build.yml", true},
+		{"ci/**", "ci/jobs/test.yml", true},
+		{"ci/**", "cicd/build.yml", false},
+		{"**/*.md", "docs/guide/intro.md", true},
+		{"**/*.md", "intro.md", true},
+		{"src/*/main.go", "src/app/main.go", true},
+		{"src/*/main.go", "src/app/cmd/main.go", false},
+		{"[", "[", false},
+	}
+
+	for _, tt := range tests {
+		if got := MatchGlob(tt.pattern, tt.name); got != tt.expected {
+			t.Errorf("MatchGlob(%q, %q) = %v; want %v", tt.pattern, tt.name, got, tt.expected)
+		}
+	}
+}
+
+func TestClosestMatch(t *testing.T) {
+	candidates := []string{"maven", "gradle", "angular", "go-service"}
+	tests := []struct {
+		word     string
+		expected string
+	}{
+		{"maven", "maven"},
+		{"mavne", "maven"},
+		{"Gradel", "gradle"},
+		{"go-servce", "go-service"},
+		{"python", ""},
+	}
+	for _, tt := range tests {
+		if got := ClosestMatch(tt.word, candidates); got != tt.expected {
+			t.Errorf("ClosestMatch(%q) = %q; want %q", tt.word, got, tt.expected)
+		}
+	}
+	if got := ClosestMatch("maven", nil); got != "" {
+		t.Errorf("ClosestMatch with no candidates = %q; want empty", got)
+	}
+}
+
+func TestDeleteKeyAndCopyMap(t *testing.T) {
+	m := map[string]interface{}{
+		"name": "demo",
+		"db": map[string]interface{}{
+			"user":     "admin",
+			"password": "secret",
+		},
+		"tags": []interface{}{"a", map[string]interface{}{"b": "c"}},
+	}
+	copied := CopyMap(m)
+
+	if !DeleteKey(copied, "db.password") {
+		t.Error("DeleteKey(db.password) = false; want true")
+	}
+	if DeleteKey(copied, "db.password") || DeleteKey(copied, "missing.key") {
+		t.Error("DeleteKey removed a missing key")
+	}
+	if _, ok := LookupKey(copied, "db.password"); ok {
+		t.Error("db.password still present after DeleteKey")
+	}
+	if _, ok := LookupKey(m, "db.password"); !ok {
+		t.Error("DeleteKey on the copy modified the original map")
+	}
+
+	copied["tags"].([]interface{})[1].(map[string]interface{})["b"] = "changed"
+	if m["tags"].([]interface{})[1].(map[string]interface{})["b"] != "c" {
+		t.Error("CopyMap shares nested lists with the original map")
+	}
+}